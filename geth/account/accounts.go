@@ -2,6 +2,7 @@ package account
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
@@ -26,8 +28,67 @@ var (
 	ErrWhisperClearIdentitiesFailure   = errors.New("failed to clear whisper identities")
 	ErrNoAccountSelected               = errors.New("no account has been selected, please login")
 	ErrInvalidMasterKeyCreated         = errors.New("can not create master extended key")
+	ErrKeyAddressMismatch              = errors.New("decrypted key doesn't match the account address")
+	ErrNilKeyStore                     = errors.New("keyStore must not be nil")
+	ErrNilExtendedKey                  = errors.New("extended key must not be nil")
+	ErrEmptyPassword                   = errors.New("password must not be empty")
 )
 
+// AccountKeyStorer defines the subset of keystore operations the account package relies on.
+// keystore.KeyStore implements it; custom backends can be registered with RegisterKeyStore.
+type AccountKeyStorer interface {
+	// ImportExtendedKey stores an extended key encrypted with a password.
+	// If the key is already stored, its account is returned.
+	ImportExtendedKey(extKey *extkeys.ExtendedKey, password string) (accounts.Account, error)
+	// AccountDecryptedKey returns a key of an account decrypted with a password.
+	// keystore.ErrNoMatch is returned for unknown accounts and
+	// keystore.ErrDecrypt if the password is wrong.
+	// The key must be a copy owned by the caller, like a freshly decrypted one,
+	// as the account package zeroes keys once it's done with them.
+	AccountDecryptedKey(account accounts.Account, password string) (accounts.Account, *keystore.Key, error)
+}
+
+// VerifyPassword checks whether a given password decrypts the key of the account identified by address.
+// It returns false if the password is wrong, and an error if the account is not known to the keystore.
+// The decrypted key is zeroed before returning, see AccountKeyStorer.
+func VerifyPassword(keyStore AccountKeyStorer, address, password string) (bool, error) {
+	account, err := common.ParseAccountString(address)
+	if err != nil {
+		return false, ErrAddressToAccountMappingFailure
+	}
+
+	_, key, err := keyStore.AccountDecryptedKey(account, password)
+	if err == keystore.ErrDecrypt {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	zeroKey(key)
+
+	return true, nil
+}
+
+// zeroKey zeroes the private key material of a decrypted key.
+func zeroKey(key *keystore.Key) {
+	if key == nil {
+		return
+	}
+
+	if key.PrivateKey != nil {
+		b := key.PrivateKey.D.Bits()
+		for i := range b {
+			b[i] = 0
+		}
+	}
+
+	if key.ExtendedKey != nil {
+		for i := range key.ExtendedKey.KeyData {
+			key.ExtendedKey.KeyData[i] = 0
+		}
+	}
+}
+
 // Manager represents account manager interface
 type Manager struct {
 	nodeManager     common.NodeManager
@@ -46,6 +107,16 @@ func NewManager(nodeManager common.NodeManager) *Manager {
 // Public key of CKD#1 is returned, with CKD#2 securely encoded into account key file (to be used for
 // sub-account derivations)
 func (m *Manager) CreateAccount(password string) (address, pubKey, mnemonic string, err error) {
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return CreateAccount(keyStore, password)
+}
+
+// CreateAccount works like Manager.CreateAccount, but imports keys into a given keystore.
+func CreateAccount(keyStore AccountKeyStorer, password string) (address, pubKey, mnemonic string, err error) {
 	// generate mnemonic phrase
 	mn := extkeys.NewMnemonic(extkeys.Salt)
 	mnemonic, err = mn.MnemonicPhrase(128, extkeys.EnglishLanguage)
@@ -60,7 +131,7 @@ func (m *Manager) CreateAccount(password string) (address, pubKey, mnemonic stri
 	}
 
 	// import created key into account keystore
-	address, pubKey, err = m.importExtendedKey(extKey, password)
+	address, pubKey, err = ImportExtendedKey(keyStore, extKey, password)
 	if err != nil {
 		return "", "", "", err
 	}
@@ -297,21 +368,105 @@ func (m *Manager) importExtendedKey(extKey *extkeys.ExtendedKey, password string
 		return "", "", err
 	}
 
+	return ImportExtendedKey(keyStore, extKey, password)
+}
+
+// AccountInfo describes an account imported with ImportExtendedKeyInfo.
+type AccountInfo struct {
+	Address         string // EIP-55 address of the account in the keystore
+	ChecksumAddress string // EIP-55 address derived from PubKey, equal to Address
+	PubKey          string // hex-encoded uncompressed public key
+}
+
+// ImportExtendedKey imports an extended key into a keystore and returns the address
+// and public key of the account. Keystore errors are wrapped into ImportError.
+func ImportExtendedKey(keyStore AccountKeyStorer, extKey *extkeys.ExtendedKey, password string) (address, pubKey string, err error) {
+	info, err := ImportExtendedKeyInfo(keyStore, extKey, password)
+	return info.Address, info.PubKey, err
+}
+
+// ImportExtendedKeyInfo works like ImportExtendedKey, but it also returns the address
+// derived from the public key. The derived address is checked to match the address
+// of the account in the keystore by importExtendedKey, see checkKeyAddress,
+// and ErrKeyAddressMismatch is returned if it doesn't.
+func ImportExtendedKeyInfo(keyStore AccountKeyStorer, extKey *extkeys.ExtendedKey, password string) (AccountInfo, error) {
+	return importExtendedKeyInfo(keyStore, extKey, password, crypto.FromECDSAPub)
+}
+
+// ImportExtendedKeyCompressed works like ImportExtendedKey, but the public key
+// is returned in the 33-byte compressed form, e.g. for libp2p identities.
+func ImportExtendedKeyCompressed(keyStore AccountKeyStorer, extKey *extkeys.ExtendedKey, password string) (address, pubKey string, err error) {
+	info, err := importExtendedKeyInfo(keyStore, extKey, password, compressPubKey)
+	return info.Address, info.PubKey, err
+}
+
+// importExtendedKeyInfo implements ImportExtendedKeyInfo with the public key
+// encoded by the given function.
+func importExtendedKeyInfo(keyStore AccountKeyStorer, extKey *extkeys.ExtendedKey, password string, encode func(*ecdsa.PublicKey) []byte) (AccountInfo, error) {
+	account, key, err := importExtendedKey(keyStore, extKey, password)
+
+	var info AccountInfo
+	if account.Address != (gethcommon.Address{}) {
+		info.Address = account.Address.Hex()
+	}
+	if err != nil {
+		return info, err
+	}
+	defer zeroKey(key)
+
+	info.PubKey = gethcommon.ToHex(encode(&key.PrivateKey.PublicKey))
+	info.ChecksumAddress = crypto.PubkeyToAddress(key.PrivateKey.PublicKey).Hex()
+
+	return info, nil
+}
+
+// compressPubKey returns the 33-byte compressed encoding of a public key.
+func compressPubKey(pub *ecdsa.PublicKey) []byte {
+	return (*btcec.PublicKey)(pub).SerializeCompressed()
+}
+
+// importExtendedKey implements ImportExtendedKey. It returns the imported account
+// and its decrypted key. If the key can't be decrypted, the account is still returned.
+func importExtendedKey(keyStore AccountKeyStorer, extKey *extkeys.ExtendedKey, password string) (accounts.Account, *keystore.Key, error) {
+	if keyStore == nil {
+		return accounts.Account{}, nil, ErrNilKeyStore
+	}
+	if extKey == nil {
+		return accounts.Account{}, nil, &ImportError{Kind: ErrInvalidKey, Err: ErrNilExtendedKey}
+	}
+	// Keys are almost never meant to be stored unencrypted.
+	if password == "" {
+		return accounts.Account{}, nil, ErrEmptyPassword
+	}
+
 	// imports extended key, create key file (if necessary)
 	account, err := keyStore.ImportExtendedKey(extKey, password)
 	if err != nil {
-		return "", "", err
+		return accounts.Account{}, nil, wrapImportError(err)
 	}
-	address = account.Address.Hex()
 
 	// obtain public key to return
-	account, key, err := keyStore.AccountDecryptedKey(account, password)
+	decryptedAccount, key, err := keyStore.AccountDecryptedKey(account, password)
 	if err != nil {
-		return address, "", err
+		return account, nil, wrapImportError(err)
 	}
-	pubKey = gethcommon.ToHex(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
 
-	return
+	if err := checkKeyAddress(account, key); err != nil {
+		return account, nil, err
+	}
+
+	return decryptedAccount, key, nil
+}
+
+// checkKeyAddress guards against a keystore returning a key of another account,
+// e.g. if it's corrupted. The key is zeroed if it doesn't match.
+func checkKeyAddress(account accounts.Account, key *keystore.Key) error {
+	if key == nil || key.PrivateKey == nil || crypto.PubkeyToAddress(key.PrivateKey.PublicKey) != account.Address {
+		zeroKey(key)
+		return ErrKeyAddressMismatch
+	}
+
+	return nil
 }
 
 // Accounts returns list of addresses for selected account, including
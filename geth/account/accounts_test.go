@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/status-im/status-go/geth/account"
 	"github.com/status-im/status-go/geth/common"
@@ -109,3 +110,27 @@ func TestVerifyAccountPasswordWithAccountBeforeEIP55(t *testing.T) {
 	_, err = acctManager.VerifyAccountPassword(keyStoreDir, address.Hex(), TestConfig.Account3.Password)
 	require.NoError(t, err)
 }
+
+func TestVerifyPassword(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	require.NoError(t, common.ImportTestAccount(keyStoreDir, GetAccount1PKFile()))
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	// correct password
+	ok, err := account.VerifyPassword(keyStore, TestConfig.Account1.Address, TestConfig.Account1.Password)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// wrong password
+	ok, err = account.VerifyPassword(keyStore, TestConfig.Account1.Address, "wrong password")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// unknown account
+	ok, err = account.VerifyPassword(keyStore, TestConfig.Account2.Address, TestConfig.Account2.Password)
+	require.Equal(t, keystore.ErrNoMatch, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,42 @@
+package account
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// address errors
+var (
+	ErrInvalidAddress  = errors.New("address must be 40 hex characters, optionally prefixed with 0x")
+	ErrAddressChecksum = errors.New("address checksum mismatch")
+)
+
+// NormalizeAddress validates an address entered by a user, with or without
+// the 0x prefix. An all-lowercase or all-uppercase address carries no
+// checksum and is accepted, while a mixed-case one must be a valid EIP-55
+// checksum, otherwise ErrAddressChecksum is returned, as it's likely a typo.
+// The returned address' Hex method gives the checksummed form.
+func NormalizeAddress(s string) (gethcommon.Address, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+
+	if len(s) != 2*gethcommon.AddressLength {
+		return gethcommon.Address{}, ErrInvalidAddress
+	}
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return gethcommon.Address{}, ErrInvalidAddress
+	}
+
+	address := gethcommon.BytesToAddress(data)
+	if s != strings.ToLower(s) && s != strings.ToUpper(s) && "0x"+s != address.Hex() {
+		return gethcommon.Address{}, ErrAddressChecksum
+	}
+
+	return address, nil
+}
@@ -0,0 +1,39 @@
+package account_test
+
+import (
+	"testing"
+
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	cases := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{"checksummed", checksummed, nil},
+		{"lowercase", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", nil},
+		{"uppercase", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", nil},
+		{"no_prefix", "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", nil},
+		{"whitespace", " " + checksummed + "\n", nil},
+		{"bad_checksum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", account.ErrAddressChecksum},
+		{"short", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea", account.ErrInvalidAddress},
+		{"long", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed00", account.ErrInvalidAddress},
+		{"not_hex", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz", account.ErrInvalidAddress},
+		{"empty", "", account.ErrInvalidAddress},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			address, err := account.NormalizeAddress(tc.input)
+			require.Equal(t, tc.err, err)
+			if tc.err == nil {
+				require.Equal(t, checksummed, address.Hex())
+			}
+		})
+	}
+}
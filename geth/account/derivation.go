@@ -0,0 +1,179 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/extkeys"
+)
+
+// ErrPublicParentKey is returned when an account is derived from a public extended key.
+var ErrPublicParentKey = errors.New("cannot derive an account from a public extended key")
+
+// DerivationSource describes where a key of an account comes from.
+type DerivationSource string
+
+// DerivationSourceMnemonic is a source of keys derived from a mnemonic
+// with DeriveAndImport, the only import path recording metadata.
+const DerivationSourceMnemonic DerivationSource = "mnemonic"
+
+// DerivationInfo describes how a key of an account was obtained.
+type DerivationInfo struct {
+	Path   string           `json:"path,omitempty"`
+	Source DerivationSource `json:"source"`
+}
+
+// DerivationStore keeps derivation metadata of imported accounts
+// and persists it in a JSON file.
+type DerivationStore struct {
+	mx    sync.RWMutex
+	path  string
+	infos map[gethcommon.Address]DerivationInfo
+}
+
+// NewDerivationStore returns a DerivationStore persisted in a file
+// with a given path. If the file exists, the metadata is loaded from it.
+func NewDerivationStore(path string) (*DerivationStore, error) {
+	store := &DerivationStore{
+		path:  path,
+		infos: make(map[gethcommon.Address]DerivationInfo),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &store.infos); err != nil {
+		return nil, fmt.Errorf("failed to read derivation metadata: %v", err)
+	}
+
+	return store, nil
+}
+
+// AccountDerivation returns derivation metadata of an account.
+func (s *DerivationStore) AccountDerivation(address string) (DerivationInfo, bool) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	info, ok := s.infos[gethcommon.HexToAddress(address)]
+	return info, ok
+}
+
+// SetAccountDerivation stores derivation metadata of an account.
+func (s *DerivationStore) SetAccountDerivation(address string, info DerivationInfo) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.infos[gethcommon.HexToAddress(address)] = info
+
+	data, err := json.Marshal(s.infos)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, data, 0600)
+}
+
+// DeriveAndImport derives a key from an English BIP-39 mnemonic following
+// a derivation path, for instance, m/44'/60'/0'/0/0, and imports it into
+// the keystore like ImportExtendedKey. The derivation metadata is saved
+// in the store. If store is nil, the metadata isn't saved. An invalid
+// mnemonic is reported as an ImportError of kind ErrInvalidMnemonic.
+func DeriveAndImport(keyStore AccountKeyStorer, store *DerivationStore, mnemonic, password, path string) (address string, err error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	if err := validateMnemonic(mn, mnemonic); err != nil {
+		return "", &ImportError{Kind: ErrInvalidMnemonic, Err: err}
+	}
+
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, password), []byte(extkeys.Salt))
+	if err != nil {
+		return "", wrapImportError(ErrInvalidMasterKeyCreated)
+	}
+
+	extKey, err := masterKey.Derive(indexes)
+	if err != nil {
+		return "", wrapImportError(err)
+	}
+
+	address, _, err = ImportExtendedKey(keyStore, extKey, password)
+	if err != nil {
+		return address, err
+	}
+
+	if store == nil {
+		return address, nil
+	}
+
+	info := DerivationInfo{
+		Path:   path,
+		Source: DerivationSourceMnemonic,
+	}
+	if err := store.SetAccountDerivation(address, info); err != nil {
+		return address, wrapImportError(err)
+	}
+
+	return address, nil
+}
+
+// DeriveChild derives a child of an extended key at a given index and imports
+// it into the keystore. Indexes starting at extkeys.HardenedKeyStart derive
+// hardened children. The parent key must be private, otherwise an ImportError
+// of kind ErrInvalidKey wrapping ErrPublicParentKey is returned.
+func DeriveChild(keyStore AccountKeyStorer, parent *extkeys.ExtendedKey, index uint32, password string) (address, pubKey string, err error) {
+	if parent == nil {
+		return "", "", &ImportError{Kind: ErrInvalidKey, Err: extkeys.ErrInvalidKey}
+	}
+	if !parent.IsPrivate {
+		return "", "", &ImportError{Kind: ErrInvalidKey, Err: ErrPublicParentKey}
+	}
+
+	child, err := parent.Child(index)
+	if err != nil {
+		return "", "", wrapImportError(err)
+	}
+
+	return ImportExtendedKey(keyStore, child, password)
+}
+
+// parseDerivationPath parses a BIP32 derivation path, like m/44'/60'/0'/0/0,
+// into child indexes. Indexes followed by an apostrophe are hardened.
+func parseDerivationPath(path string) ([]uint32, error) {
+	components := strings.Split(path, "/")
+	if len(components) == 0 || components[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path: %s", path)
+	}
+
+	indexes := make([]uint32, 0, len(components)-1)
+	for _, component := range components[1:] {
+		var offset uint32
+		if strings.HasSuffix(component, "'") {
+			offset = extkeys.HardenedKeyStart
+			component = strings.TrimSuffix(component, "'")
+		}
+
+		index, err := strconv.ParseUint(component, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path: %s", path)
+		}
+
+		indexes = append(indexes, uint32(index)+offset)
+	}
+
+	return indexes, nil
+}
@@ -0,0 +1,105 @@
+package account_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveAndImport(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	metadataPath := filepath.Join(keyStoreDir, "derivations.json")
+	store, err := account.NewDerivationStore(metadataPath)
+	require.NoError(t, err)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	path := "m/44'/60'/0'/0/0"
+
+	_, err = account.DeriveAndImport(keyStore, store, mnemonic, "password", "44'/60'")
+	require.EqualError(t, err, "invalid derivation path: 44'/60'")
+
+	// the same preconditions as for other imports apply
+	_, err = account.DeriveAndImport(nil, store, mnemonic, "password", path)
+	require.Equal(t, account.ErrNilKeyStore, err)
+	_, err = account.DeriveAndImport(keyStore, store, mnemonic, "", path)
+	require.Equal(t, account.ErrEmptyPassword, err)
+	_, err = account.DeriveAndImport(keyStore, store, "abandon abandon abandon", "password", path)
+	require.True(t, errors.Is(err, account.ErrInvalidMnemonic), "unexpected error: %v", err)
+
+	address, err := account.DeriveAndImport(keyStore, store, mnemonic, "password", path)
+	require.NoError(t, err)
+
+	info, ok := store.AccountDerivation(address)
+	require.True(t, ok)
+	require.Equal(t, account.DerivationInfo{Path: path, Source: account.DerivationSourceMnemonic}, info)
+
+	// metadata is persisted
+	store, err = account.NewDerivationStore(metadataPath)
+	require.NoError(t, err)
+	info, ok = store.AccountDerivation(address)
+	require.True(t, ok)
+	require.Equal(t, path, info.Path)
+
+	_, ok = store.AccountDerivation("0xadaf150b905cf5e6a778e553e15a139b6618bbb7")
+	require.False(t, ok)
+}
+
+func TestDeriveChild(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	store, err := account.NewDerivationStore(filepath.Join(keyStoreDir, "derivations.json"))
+	require.NoError(t, err)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, "password"), []byte(extkeys.Salt))
+	require.NoError(t, err)
+	parent, err := masterKey.Derive([]uint32{
+		extkeys.HardenedKeyStart + 44,
+		extkeys.HardenedKeyStart + 60,
+		extkeys.HardenedKeyStart + 0,
+		0,
+	})
+	require.NoError(t, err)
+
+	// non-hardened child
+	address, pubKey, err := account.DeriveChild(keyStore, parent, 1, "password")
+	require.NoError(t, err)
+	require.NotEmpty(t, pubKey)
+
+	expected, err := account.DeriveAndImport(keyStore, store, mnemonic, "password", "m/44'/60'/0'/0/1")
+	require.NoError(t, err)
+	require.Equal(t, expected, address)
+
+	// hardened child
+	hardenedAddress, _, err := account.DeriveChild(keyStore, parent, extkeys.HardenedKeyStart+1, "password")
+	require.NoError(t, err)
+
+	expected, err = account.DeriveAndImport(keyStore, store, mnemonic, "password", "m/44'/60'/0'/0/1'")
+	require.NoError(t, err)
+	require.Equal(t, expected, hardenedAddress)
+	require.NotEqual(t, address, hardenedAddress)
+
+	// public parent keys are rejected
+	publicParent, err := parent.Neuter()
+	require.NoError(t, err)
+	for _, index := range []uint32{1, extkeys.HardenedKeyStart + 1} {
+		_, _, err = account.DeriveChild(keyStore, publicParent, index, "password")
+		require.True(t, errors.Is(err, account.ErrPublicParentKey), "unexpected error: %v", err)
+		require.True(t, errors.Is(err, account.ErrInvalidKey))
+	}
+}
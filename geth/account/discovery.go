@@ -0,0 +1,93 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// DefaultGapLimit is a default number of consecutive unused addresses
+// after which DiscoverAccounts stops, as recommended by BIP44.
+const DefaultGapLimit = 20
+
+// ErrNilRPCClient is returned when an RPC client is required but it's nil.
+var ErrNilRPCClient = errors.New("RPC client is nil")
+
+// DiscoverAccounts finds accounts with on-chain activity among BIP44 children
+// of a master key, e.g. when a wallet is restored from a seed. Children are
+// derived one by one, and an address is considered used if it sent
+// transactions or has a balance. The scan stops after gapLimit consecutive
+// unused addresses; a non-positive gapLimit means DefaultGapLimit.
+// Used accounts are imported into the keystore and their addresses returned.
+func DiscoverAccounts(keyStore AccountKeyStorer, master *extkeys.ExtendedKey, client *rpc.Client, gapLimit int, password string) ([]string, error) {
+	if keyStore == nil {
+		return nil, ErrNilKeyStore
+	}
+	if master == nil {
+		return nil, &ImportError{Kind: ErrInvalidKey, Err: ErrNilExtendedKey}
+	}
+	if client == nil {
+		return nil, ErrNilRPCClient
+	}
+	if password == "" {
+		return nil, ErrEmptyPassword
+	}
+	if gapLimit <= 0 {
+		gapLimit = DefaultGapLimit
+	}
+
+	var addresses []string
+	for i, unused := uint32(0), 0; unused < gapLimit && i < extkeys.HardenedKeyStart; i++ {
+		child, err := master.BIP44Child(extkeys.CoinTypeETH, i)
+		if err != nil {
+			return addresses, &ImportError{Kind: ErrInvalidKey, Err: err}
+		}
+
+		used, err := addressUsed(client, crypto.PubkeyToAddress(child.ToECDSA().PublicKey))
+		if err != nil {
+			return addresses, err
+		}
+		if !used {
+			unused++
+			continue
+		}
+		unused = 0
+
+		address, _, err := ImportExtendedKey(keyStore, child, password)
+		if err != nil {
+			return addresses, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// addressUsed returns true if an address sent transactions or has a balance.
+func addressUsed(client *rpc.Client, address gethcommon.Address) (bool, error) {
+	var (
+		nonce   hexutil.Uint64
+		balance hexutil.Big
+	)
+	batch := []gethrpc.BatchElem{
+		{Method: "eth_getTransactionCount", Args: []interface{}{address, "latest"}, Result: &nonce},
+		{Method: "eth_getBalance", Args: []interface{}{address, "latest"}, Result: &balance},
+	}
+	if err := client.BatchCallContext(context.Background(), batch); err != nil {
+		return false, err
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return false, elem.Error
+		}
+	}
+
+	return nonce > 0 || (*big.Int)(&balance).Sign() > 0, nil
+}
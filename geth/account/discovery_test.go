@@ -0,0 +1,74 @@
+package account_test
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverAccounts(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	master, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, "password"), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	addresses := make([]gethcommon.Address, 10)
+	for i := range addresses {
+		child, err := master.BIP44Child(extkeys.CoinTypeETH, uint32(i))
+		require.NoError(t, err)
+		addresses[i] = crypto.PubkeyToAddress(child.ToECDSA().PublicKey)
+	}
+
+	// the accounts 0 and 6 sent transactions, the account 2 received ether
+	nonces := map[gethcommon.Address]uint64{addresses[0]: 3, addresses[6]: 1}
+	balances := map[gethcommon.Address]int64{addresses[2]: 1000}
+
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	require.NoError(t, err)
+	client.RegisterHandler("eth_getTransactionCount", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return hexutil.Uint64(nonces[args[0].(gethcommon.Address)]), nil
+	})
+	client.RegisterHandler("eth_getBalance", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(balances[args[0].(gethcommon.Address)])), nil
+	})
+
+	// the scan stops after 3 unused addresses
+	found, err := account.DiscoverAccounts(keyStore, master, client, 3, "password")
+	require.NoError(t, err)
+	require.Equal(t, []string{addresses[0].Hex(), addresses[2].Hex()}, found)
+
+	for _, address := range found {
+		ok, err := account.VerifyPassword(keyStore, address, "password")
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	// the default gap limit is 20
+	found, err = account.DiscoverAccounts(keyStore, master, client, 0, "password")
+	require.NoError(t, err)
+	require.Equal(t, []string{addresses[0].Hex(), addresses[2].Hex(), addresses[6].Hex()}, found)
+
+	// preconditions are checked
+	_, err = account.DiscoverAccounts(keyStore, master, nil, 0, "password")
+	require.Equal(t, account.ErrNilRPCClient, err)
+	_, err = account.DiscoverAccounts(keyStore, master, client, 0, "")
+	require.Equal(t, account.ErrEmptyPassword, err)
+}
@@ -0,0 +1,92 @@
+package account
+
+import (
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+)
+
+// ErrNoExtendedKey is returned when an account isn't stored with an extended key,
+// e.g. it was imported from a private key.
+var ErrNoExtendedKey = errors.New("account has no extended key")
+
+// ImportAndExport imports an extended key like ImportExtendedKey and also
+// returns the key of the account in the Web3 Secret Storage (V3) format,
+// encrypted with the password, to be used as a backup.
+// The key file of a file-based keystore is returned as is,
+// otherwise the key decrypted during the import is encrypted again.
+func ImportAndExport(keyStore AccountKeyStorer, extKey *extkeys.ExtendedKey, password string) (address, pubKey string, keyJSON []byte, err error) {
+	account, key, err := importExtendedKey(keyStore, extKey, password)
+	if err != nil {
+		return "", "", nil, err
+	}
+	defer zeroKey(key)
+
+	address = account.Address.Hex()
+	pubKey = gethcommon.ToHex(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
+
+	if account.URL.Scheme == keystore.KeyStoreScheme {
+		if keyJSON, err = ioutil.ReadFile(account.URL.Path); err == nil {
+			return address, pubKey, keyJSON, nil
+		}
+	}
+
+	keyJSON, err = keystore.EncryptKey(key, password, keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return address, pubKey, nil, wrapImportError(err)
+	}
+
+	return address, pubKey, keyJSON, nil
+}
+
+// ExportExtendedKey decrypts the key of an account and returns the extended key
+// stored with it. For accounts imported from a master key, it's the root
+// of sub-accounts. ErrWrongPassword is returned if the password is wrong.
+func ExportExtendedKey(keyStore AccountKeyStorer, address gethcommon.Address, password string) (*extkeys.ExtendedKey, error) {
+	key, err := decryptAccountKey(keyStore, address, password)
+	if err != nil {
+		return nil, err
+	}
+
+	extKey := key.ExtendedKey
+	key.ExtendedKey = nil
+	zeroKey(key)
+
+	if extKey == nil || len(extKey.KeyData) == 0 {
+		return nil, ErrNoExtendedKey
+	}
+
+	return extKey, nil
+}
+
+// ExportPrivateKeyHex decrypts the key of an account and returns its
+// private key hex-encoded without the 0x prefix, as expected by MetaMask.
+// ErrWrongPassword is returned if the password is wrong.
+func ExportPrivateKeyHex(keyStore AccountKeyStorer, address gethcommon.Address, password string) (string, error) {
+	key, err := decryptAccountKey(keyStore, address, password)
+	if err != nil {
+		return "", err
+	}
+	defer zeroKey(key)
+
+	return hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)), nil
+}
+
+// decryptAccountKey returns a decrypted key of an account.
+func decryptAccountKey(keyStore AccountKeyStorer, address gethcommon.Address, password string) (*keystore.Key, error) {
+	_, key, err := keyStore.AccountDecryptedKey(accounts.Account{Address: address}, password)
+	if err == keystore.ErrDecrypt {
+		return nil, ErrWrongPassword
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
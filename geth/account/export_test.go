@@ -0,0 +1,89 @@
+package account_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportAndExport(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	extKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, "password"), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	address, pubKey, keyJSON, err := account.ImportAndExport(keyStore, extKey, "password")
+	require.NoError(t, err)
+	require.NotEmpty(t, pubKey)
+
+	// the exported key re-imports to the same address
+	otherKeyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(otherKeyStoreDir) //nolint: errcheck
+
+	otherKeyStore := keystore.NewKeyStore(otherKeyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	imported, err := otherKeyStore.Import(keyJSON, "password", "password")
+	require.NoError(t, err)
+	require.Equal(t, address, imported.Address.Hex())
+
+	_, _, _, err = account.ImportAndExport(keyStore, nil, "password")
+	require.Equal(t, account.ErrInvalidKey, err.(*account.ImportError).Kind)
+}
+
+func TestExportKeys(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	extKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, "password"), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	address, _, err := account.ImportExtendedKey(keyStore, extKey, "password")
+	require.NoError(t, err)
+
+	// the root of sub-accounts is stored with the account
+	subAccountsRoot, err := extKey.BIP44Child(extkeys.CoinTypeETH, 1)
+	require.NoError(t, err)
+	exported, err := account.ExportExtendedKey(keyStore, gethcommon.HexToAddress(address), "password")
+	require.NoError(t, err)
+	require.Equal(t, subAccountsRoot.String(), exported.String())
+
+	_, err = account.ExportExtendedKey(keyStore, gethcommon.HexToAddress(address), "wrong password")
+	require.Equal(t, account.ErrWrongPassword, err)
+
+	// the exported private key re-imports to the same address
+	privateKeyHex, err := account.ExportPrivateKeyHex(keyStore, gethcommon.HexToAddress(address), "password")
+	require.NoError(t, err)
+
+	otherKeyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(otherKeyStoreDir) //nolint: errcheck
+
+	otherKeyStore := keystore.NewKeyStore(otherKeyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	imported, _, err := account.ImportPrivateKey(otherKeyStore, privateKeyHex, "password")
+	require.NoError(t, err)
+	require.Equal(t, address, imported)
+
+	_, err = account.ExportPrivateKeyHex(keyStore, gethcommon.HexToAddress(address), "wrong password")
+	require.Equal(t, account.ErrWrongPassword, err)
+
+	// accounts imported from private keys have no extended key
+	_, err = account.ExportExtendedKey(otherKeyStore, gethcommon.HexToAddress(imported), "password")
+	require.Equal(t, account.ErrNoExtendedKey, err)
+}
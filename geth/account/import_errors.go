@@ -0,0 +1,83 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/extkeys"
+)
+
+// import errors
+var (
+	ErrWrongPassword   = errors.New("wrong password")
+	ErrKeystoreIO      = errors.New("keystore I/O failure")
+	ErrInvalidKey      = errors.New("invalid key")
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+)
+
+// ImportError is returned when a key can't be imported into the keystore.
+// It can be matched against ErrWrongPassword, ErrKeystoreIO, ErrInvalidKey
+// and ErrInvalidMnemonic with errors.Is, while errors.Unwrap returns the underlying keystore error.
+type ImportError struct {
+	Kind error // one of the import errors
+	Err  error // underlying error
+}
+
+// Error implements the error interface.
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error is of a given kind.
+func (e *ImportError) Is(target error) bool {
+	return e.Kind == target
+}
+
+// invalidKeyErrors are errors returned for malformed keys.
+var invalidKeyErrors = []error{
+	ErrInvalidMasterKeyCreated,
+	extkeys.ErrInvalidKey,
+	extkeys.ErrInvalidSeed,
+	extkeys.ErrInvalidSeedLen,
+	extkeys.ErrDerivingHardenedFromPublic,
+	extkeys.ErrBadChecksum,
+	extkeys.ErrInvalidKeyLen,
+	extkeys.ErrDerivingChild,
+	extkeys.ErrInvalidMasterKey,
+}
+
+// wrapImportError wraps a keystore error into ImportError if it's classified.
+// Other errors are returned unchanged.
+func wrapImportError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*ImportError); ok {
+		return err
+	}
+
+	if err == keystore.ErrDecrypt {
+		return &ImportError{Kind: ErrWrongPassword, Err: err}
+	}
+
+	for _, invalidKeyErr := range invalidKeyErrors {
+		if err == invalidKeyErr {
+			return &ImportError{Kind: ErrInvalidKey, Err: err}
+		}
+	}
+
+	switch err.(type) {
+	case *os.PathError, *os.LinkError, *os.SyscallError:
+		return &ImportError{Kind: ErrKeystoreIO, Err: err}
+	}
+
+	return err
+}
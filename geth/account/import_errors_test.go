@@ -0,0 +1,195 @@
+package account_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportErrors(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	// successful import
+	_, _, err = account.ImportExtendedKey(keyStore, masterKey, "password")
+	require.NoError(t, err)
+
+	// wrong password of an already imported key
+	_, _, err = account.ImportExtendedKey(keyStore, masterKey, "wrong password")
+	require.True(t, errors.Is(err, account.ErrWrongPassword), "unexpected error: %v", err)
+	require.True(t, errors.Is(err, keystore.ErrDecrypt))
+
+	// invalid key
+	publicKey, err := masterKey.Neuter()
+	require.NoError(t, err)
+	_, _, err = account.ImportExtendedKey(keyStore, publicKey, "password")
+	require.True(t, errors.Is(err, account.ErrInvalidKey), "unexpected error: %v", err)
+
+	_, _, err = account.ImportExtendedKey(keyStore, nil, "password")
+	require.True(t, errors.Is(err, account.ErrInvalidKey), "unexpected error: %v", err)
+
+	// keystore directory can't be created as its parent is a file
+	parentFile := filepath.Join(keyStoreDir, "file")
+	require.NoError(t, ioutil.WriteFile(parentFile, nil, 0600))
+	brokenKeyStore := keystore.NewKeyStore(filepath.Join(parentFile, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	_, _, err = account.ImportExtendedKey(brokenKeyStore, masterKey, "password")
+	require.True(t, errors.Is(err, account.ErrKeystoreIO), "unexpected error: %v", err)
+
+	// the same errors are returned when deriving keys
+	store, err := account.NewDerivationStore(filepath.Join(keyStoreDir, "derivations.json"))
+	require.NoError(t, err)
+	_, err = account.DeriveAndImport(brokenKeyStore, store, mnemonic, "password", "m/44'/60'/0'/0/0")
+	require.True(t, errors.Is(err, account.ErrKeystoreIO), "unexpected error: %v", err)
+}
+
+// mismatchedKeyStore returns keys of another account when they are decrypted.
+type mismatchedKeyStore struct {
+	*keystore.KeyStore
+}
+
+func (s mismatchedKeyStore) AccountDecryptedKey(a accounts.Account, password string) (accounts.Account, *keystore.Key, error) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return a, nil, err
+	}
+
+	return a, &keystore.Key{Address: a.Address, PrivateKey: privateKey}, nil
+}
+
+func TestImportKeyAddressMismatch(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := mismatchedKeyStore{keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)}
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	_, pubKey, err := account.ImportExtendedKey(keyStore, masterKey, "password")
+	require.Equal(t, account.ErrKeyAddressMismatch, err)
+	require.Empty(t, pubKey)
+
+	_, _, err = account.ImportPrivateKey(keyStore, "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318", "password")
+	require.Equal(t, account.ErrKeyAddressMismatch, err)
+}
+
+func TestImportExtendedKeyCompressed(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	address, pubKey, err := account.ImportExtendedKey(keyStore, masterKey, "password")
+	require.NoError(t, err)
+	compressedAddress, compressedPubKey, err := account.ImportExtendedKeyCompressed(keyStore, masterKey, "password")
+	require.NoError(t, err)
+	require.Equal(t, address, compressedAddress)
+
+	uncompressed := hexutil.MustDecode(pubKey)
+	compressed := hexutil.MustDecode(compressedPubKey)
+	require.Len(t, uncompressed, 65)
+	require.Len(t, compressed, 33)
+
+	// both encodings decode to the same point
+	decoded, err := btcec.ParsePubKey(compressed, btcec.S256())
+	require.NoError(t, err)
+	expected := crypto.ToECDSAPub(uncompressed)
+	require.Equal(t, 0, expected.X.Cmp(decoded.X))
+	require.Equal(t, 0, expected.Y.Cmp(decoded.Y))
+}
+
+func TestImportExtendedKeyInfo(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	info, err := account.ImportExtendedKeyInfo(keyStore, masterKey, "password")
+	require.NoError(t, err)
+	require.Equal(t, info.Address, info.ChecksumAddress)
+	require.Equal(t, crypto.PubkeyToAddress(*crypto.ToECDSAPub(hexutil.MustDecode(info.PubKey))).Hex(), info.ChecksumAddress)
+
+	// ImportExtendedKey returns the same account
+	address, pubKey, err := account.ImportExtendedKey(keyStore, masterKey, "password")
+	require.NoError(t, err)
+	require.Equal(t, info.Address, address)
+	require.Equal(t, info.PubKey, pubKey)
+
+	// keys of other accounts are rejected
+	_, err = account.ImportExtendedKeyInfo(mismatchedKeyStore{keyStore}, masterKey, "password")
+	require.Equal(t, account.ErrKeyAddressMismatch, err)
+	_, _, err = account.ImportExtendedKeyCompressed(mismatchedKeyStore{keyStore}, masterKey, "password")
+	require.Equal(t, account.ErrKeyAddressMismatch, err)
+}
+
+func TestImportExtendedKeyPreconditions(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		keyStore account.AccountKeyStorer
+		extKey   *extkeys.ExtendedKey
+		password string
+		err      string
+	}{
+		{"nil keyStore", nil, masterKey, "password", "keyStore must not be nil"},
+		{"nil extended key", keyStore, nil, "password", "invalid key: extended key must not be nil"},
+		{"empty password", keyStore, masterKey, "", "password must not be empty"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			address, pubKey, err := account.ImportExtendedKey(tc.keyStore, tc.extKey, tc.password)
+			require.EqualError(t, err, tc.err)
+			require.Empty(t, address)
+			require.Empty(t, pubKey)
+		})
+	}
+
+	accounts, err := ioutil.ReadDir(keyStoreDir)
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+}
@@ -0,0 +1,84 @@
+package account
+
+import (
+	"errors"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+)
+
+// ErrNoKeysImported is returned by ImportExtendedKeys if none of the keys could be imported.
+var ErrNoKeysImported = errors.New("none of the keys could be imported")
+
+// ImportResult is a result of importing an extended key with ImportExtendedKeys.
+type ImportResult struct {
+	Address string
+	PubKey  string
+	Err     error // error of ImportExtendedKey, nil if the key was imported
+}
+
+// ImportExtendedKeys imports several extended keys encrypted with the same password,
+// e.g. when a multi-account wallet is restored. A key which can't be imported doesn't
+// abort the rest, its error is returned in its result. Results are in the order of keys.
+// ErrNoKeysImported is returned along with the results only if every import failed.
+//
+// Unlike ImportExtendedKey, the password is checked by decrypting a single imported key
+// per batch, public keys are derived from the extended keys instead.
+func ImportExtendedKeys(keyStore AccountKeyStorer, keys []*extkeys.ExtendedKey, password string) ([]ImportResult, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	results := make([]ImportResult, len(keys))
+	failed := 0
+	verified := false
+	for i, extKey := range keys {
+		result := importBatchKey(keyStore, extKey, password, !verified)
+		if result.Err == nil {
+			verified = true
+		} else {
+			failed++
+		}
+		results[i] = result
+	}
+
+	if failed == len(keys) {
+		return results, ErrNoKeysImported
+	}
+
+	return results, nil
+}
+
+// importBatchKey imports a key of a batch. The key is decrypted only if verify is true.
+func importBatchKey(keyStore AccountKeyStorer, extKey *extkeys.ExtendedKey, password string, verify bool) ImportResult {
+	if verify {
+		address, pubKey, err := ImportExtendedKey(keyStore, extKey, password)
+		return ImportResult{Address: address, PubKey: pubKey, Err: err}
+	}
+
+	// the key store and the password were checked with the verified key
+	if extKey == nil {
+		return ImportResult{Err: &ImportError{Kind: ErrInvalidKey, Err: ErrNilExtendedKey}}
+	}
+
+	account, err := keyStore.ImportExtendedKey(extKey, password)
+	if err != nil {
+		return ImportResult{Err: wrapImportError(err)}
+	}
+
+	result := ImportResult{Address: account.Address.Hex()}
+	pub, err := extendedKeyPublicKey(extKey)
+	if err != nil {
+		result.Err = wrapImportError(err)
+		return result
+	}
+	// like checkKeyAddress, but without decrypting the key
+	if crypto.PubkeyToAddress(*pub) != account.Address {
+		result.Err = ErrKeyAddressMismatch
+		return result
+	}
+	result.PubKey = gethcommon.ToHex(crypto.FromECDSAPub(pub))
+
+	return result
+}
@@ -0,0 +1,62 @@
+package account_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+// countingKeyStore counts decrypted keys.
+type countingKeyStore struct {
+	account.AccountKeyStorer
+	decrypted int
+}
+
+func (s *countingKeyStore) AccountDecryptedKey(acc accounts.Account, password string) (accounts.Account, *keystore.Key, error) {
+	s.decrypted++
+	return s.AccountKeyStorer.AccountDecryptedKey(acc, password)
+}
+
+func TestImportExtendedKeys(t *testing.T) {
+	keyStore := &countingKeyStore{AccountKeyStorer: account.NewMemoryKeyStore()}
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	var keys []*extkeys.ExtendedKey
+	for i := uint32(0); i < 3; i++ {
+		key, err := masterKey.BIP44Child(extkeys.CoinTypeETH, i)
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	publicKey, err := keys[1].Neuter()
+	require.NoError(t, err)
+
+	// a bad key doesn't abort the rest
+	results, err := account.ImportExtendedKeys(keyStore, []*extkeys.ExtendedKey{keys[0], publicKey, keys[2]}, "password")
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, 1, keyStore.decrypted, "the password is verified once per batch")
+	for _, i := range []int{0, 2} {
+		require.NoError(t, results[i].Err)
+		address, pubKey, err := account.ImportExtendedKey(keyStore, keys[i], "password")
+		require.NoError(t, err)
+		require.Equal(t, address, results[i].Address)
+		require.Equal(t, pubKey, results[i].PubKey)
+	}
+	require.True(t, errors.Is(results[1].Err, account.ErrInvalidKey), "unexpected error: %v", results[1].Err)
+
+	// an error is returned only if every import failed
+	results, err = account.ImportExtendedKeys(keyStore, []*extkeys.ExtendedKey{publicKey, nil}, "password")
+	require.Equal(t, account.ErrNoKeysImported, err)
+	require.Len(t, results, 2)
+	require.Error(t, results[0].Err)
+	require.Error(t, results[1].Err)
+}
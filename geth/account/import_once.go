@@ -0,0 +1,77 @@
+package account
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+)
+
+// ErrAlreadyImported is returned by ImportExtendedKeyOnce if the account of a key is already in the keystore.
+var ErrAlreadyImported = errors.New("account is already imported")
+
+// ImportExtendedKeyOnce works like ImportExtendedKey, but it detects if the account
+// of the key is already in the keystore, e.g. when a wallet is restored twice,
+// and returns ErrAlreadyImported. If allowExisting is true, the address and public key
+// of the existing account are returned along with ErrAlreadyImported, so that callers
+// can ignore the error. In both cases the password must decrypt the existing key,
+// otherwise the lookup error is returned.
+func ImportExtendedKeyOnce(keyStore AccountKeyStorer, extKey *extkeys.ExtendedKey, password string, allowExisting bool) (address, pubKey string, err error) {
+	if keyStore == nil || extKey == nil || !extKey.IsPrivate || password == "" {
+		// let ImportExtendedKey report the error
+		return ImportExtendedKey(keyStore, extKey, password)
+	}
+
+	accountAddress, err := extendedKeyAddress(extKey)
+	if err != nil {
+		return "", "", wrapImportError(err)
+	}
+
+	account, key, err := keyStore.AccountDecryptedKey(accounts.Account{Address: accountAddress}, password)
+	if err == keystore.ErrNoMatch {
+		return ImportExtendedKey(keyStore, extKey, password)
+	}
+	defer zeroKey(key)
+
+	address = accountAddress.Hex()
+	if err != nil {
+		return address, "", wrapImportError(err)
+	}
+	if !allowExisting {
+		return address, "", ErrAlreadyImported
+	}
+	if err := checkKeyAddress(account, key); err != nil {
+		return address, "", err
+	}
+	pubKey = gethcommon.ToHex(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
+
+	return address, pubKey, ErrAlreadyImported
+}
+
+// extendedKeyAddress returns the address of the account an extended key is imported as.
+func extendedKeyAddress(extKey *extkeys.ExtendedKey) (gethcommon.Address, error) {
+	pub, err := extendedKeyPublicKey(extKey)
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// extendedKeyPublicKey returns the public key of the account an extended key is imported as.
+// Like in keystores, master keys are imported as their first BIP44 child.
+func extendedKeyPublicKey(extKey *extkeys.ExtendedKey) (*ecdsa.PublicKey, error) {
+	accountKey := extKey
+	if extKey.Depth == 0 {
+		var err error
+		if accountKey, err = extKey.BIP44Child(extkeys.CoinTypeETH, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return &accountKey.ToECDSA().PublicKey, nil
+}
@@ -0,0 +1,84 @@
+package account_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportExtendedKeyOnce(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+	childKey, err := masterKey.BIP44Child(extkeys.CoinTypeETH, 5)
+	require.NoError(t, err)
+
+	keyStores := map[string]account.AccountKeyStorer{
+		"keystore": keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP),
+		"memory":   account.NewMemoryKeyStore(),
+	}
+	for name, keyStore := range keyStores {
+		for _, extKey := range []*extkeys.ExtendedKey{masterKey, childKey} {
+			address, pubKey, err := account.ImportExtendedKeyOnce(keyStore, extKey, "password", false)
+			require.NoError(t, err, name)
+
+			// strict callers get an error
+			_, existingPubKey, err := account.ImportExtendedKeyOnce(keyStore, extKey, "password", false)
+			require.Equal(t, account.ErrAlreadyImported, err, name)
+			require.Empty(t, existingPubKey, name)
+
+			// others get the existing account
+			existingAddress, existingPubKey, err := account.ImportExtendedKeyOnce(keyStore, extKey, "password", true)
+			require.Equal(t, account.ErrAlreadyImported, err, name)
+			require.Equal(t, address, existingAddress, name)
+			require.Equal(t, pubKey, existingPubKey, name)
+
+			// as long as the password is right
+			for _, allowExisting := range []bool{false, true} {
+				_, _, err = account.ImportExtendedKeyOnce(keyStore, extKey, "wrong password", allowExisting)
+				require.True(t, errors.Is(err, account.ErrWrongPassword), "unexpected error: %v", err)
+			}
+		}
+	}
+}
+
+// failingKeyStore fails to look up keys.
+type failingKeyStore struct {
+	account.AccountKeyStorer
+	err error
+}
+
+func (s failingKeyStore) AccountDecryptedKey(accounts.Account, string) (accounts.Account, *keystore.Key, error) {
+	return accounts.Account{}, nil, s.err
+}
+
+func TestImportExtendedKeyOnceLookupError(t *testing.T) {
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	keyStore := failingKeyStore{
+		AccountKeyStorer: account.NewMemoryKeyStore(),
+		err:              &os.PathError{Op: "open", Path: "keystore", Err: syscall.EACCES},
+	}
+
+	// the account isn't reported as imported if it can't be looked up
+	for _, allowExisting := range []bool{false, true} {
+		_, _, err = account.ImportExtendedKeyOnce(keyStore, masterKey, "password", allowExisting)
+		require.True(t, errors.Is(err, account.ErrKeystoreIO), "unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package account
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/extkeys"
+)
+
+// DefaultKeyStoreBackend is a name of the backend storing keys
+// in files of a keystore directory given as its configuration.
+const DefaultKeyStoreBackend = "keystore"
+
+// KeyStoreFactory opens a key store backend with a backend-specific configuration.
+type KeyStoreFactory func(cfg string) (AccountKeyStorer, error)
+
+var (
+	keyStoresMx sync.RWMutex
+	keyStores   = map[string]KeyStoreFactory{
+		DefaultKeyStoreBackend: func(dir string) (AccountKeyStorer, error) {
+			return keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP), nil
+		},
+	}
+)
+
+// RegisterKeyStore makes a key store backend available by a name.
+// It panics if the factory is nil or a backend with the name is already registered.
+func RegisterKeyStore(name string, factory func(cfg string) (AccountKeyStorer, error)) {
+	keyStoresMx.Lock()
+	defer keyStoresMx.Unlock()
+
+	if factory == nil {
+		panic("account: RegisterKeyStore factory is nil")
+	}
+	if _, ok := keyStores[name]; ok {
+		panic("account: RegisterKeyStore called twice for backend " + name)
+	}
+
+	keyStores[name] = factory
+}
+
+// KeyStoreBackends returns sorted names of registered key store backends.
+func KeyStoreBackends() []string {
+	keyStoresMx.RLock()
+	defer keyStoresMx.RUnlock()
+
+	names := make([]string, 0, len(keyStores))
+	for name := range keyStores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// OpenKeyStore opens a registered key store backend.
+func OpenKeyStore(name, cfg string) (AccountKeyStorer, error) {
+	keyStoresMx.RLock()
+	factory, ok := keyStores[name]
+	keyStoresMx.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key store backend: %s", name)
+	}
+
+	return factory(cfg)
+}
+
+// ImportExtendedKeyToBackend works like ImportExtendedKey,
+// but the key is imported into a named key store backend.
+func ImportExtendedKeyToBackend(backend, cfg string, extKey *extkeys.ExtendedKey, password string) (address, pubKey string, err error) {
+	keyStore, err := OpenKeyStore(backend, cfg)
+	if err != nil {
+		return "", "", err
+	}
+
+	return ImportExtendedKey(keyStore, extKey, password)
+}
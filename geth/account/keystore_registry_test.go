@@ -0,0 +1,49 @@
+package account_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterKeyStore(t *testing.T) {
+	// The registry is global, so the name is unique for repeated runs.
+	backend := fmt.Sprintf("memory-%d", time.Now().UnixNano())
+	store := account.NewMemoryKeyStore()
+	account.RegisterKeyStore(backend, func(cfg string) (account.AccountKeyStorer, error) {
+		return store, nil
+	})
+	require.Contains(t, account.KeyStoreBackends(), account.DefaultKeyStoreBackend)
+	require.Contains(t, account.KeyStoreBackends(), backend)
+
+	require.Panics(t, func() {
+		account.RegisterKeyStore(backend, func(cfg string) (account.AccountKeyStorer, error) {
+			return store, nil
+		})
+	})
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	extKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+	childKey, err := extKey.BIP44Child(extkeys.CoinTypeETH, 0)
+	require.NoError(t, err)
+
+	address, pubKey, err := account.ImportExtendedKeyToBackend(backend, "", childKey, "password")
+	require.NoError(t, err)
+	require.NotEmpty(t, pubKey)
+
+	// verifying zeroes only a decrypted copy of the stored key
+	for i := 0; i < 2; i++ {
+		ok, err := account.VerifyPassword(store, address, "password")
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	_, _, err = account.ImportExtendedKeyToBackend("unknown", "", childKey, "password")
+	require.EqualError(t, err, "unknown key store backend: unknown")
+}
@@ -0,0 +1,116 @@
+package account
+
+import (
+	"crypto/ecdsa"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pborman/uuid"
+	"github.com/status-im/status-go/extkeys"
+)
+
+// MemoryKeyStoreScheme is a URL scheme of accounts stored in MemoryKeyStore.
+const MemoryKeyStoreScheme = "memory"
+
+// MemoryKeyStore is an AccountKeyStorer keeping keys in memory, which is suitable
+// for tests and ephemeral accounts. Like keystore.KeyStore, it keeps keys encrypted
+// with their passwords, so that wrong passwords are rejected with keystore.ErrDecrypt,
+// but it uses light scrypt parameters. It also supports importing raw private keys.
+type MemoryKeyStore struct {
+	mx   sync.RWMutex
+	keys map[gethcommon.Address][]byte // encrypted keys
+}
+
+// NewMemoryKeyStore returns an empty MemoryKeyStore.
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{
+		keys: make(map[gethcommon.Address][]byte),
+	}
+}
+
+// ImportExtendedKey stores an extended key encrypted with a password.
+// As in keystore.KeyStore, a master key is stored as its BIP44 account
+// with the sub-accounts root as the extended key.
+// If the key is already stored, its account is returned.
+func (s *MemoryKeyStore) ImportExtendedKey(extKey *extkeys.ExtendedKey, password string) (accounts.Account, error) {
+	if !extKey.IsPrivate {
+		return accounts.Account{}, extkeys.ErrInvalidKey
+	}
+
+	accountKey, rootKey := extKey, extKey
+	if extKey.Depth == 0 {
+		var err error
+		if accountKey, err = extKey.BIP44Child(extkeys.CoinTypeETH, 0); err != nil {
+			return accounts.Account{}, err
+		}
+		if rootKey, err = extKey.BIP44Child(extkeys.CoinTypeETH, 1); err != nil {
+			return accounts.Account{}, err
+		}
+	}
+
+	return s.importKey(accountKey.ToECDSA(), rootKey, password)
+}
+
+// ImportECDSA stores a private key encrypted with a password.
+// If the key is already stored, its account is returned.
+func (s *MemoryKeyStore) ImportECDSA(privateKey *ecdsa.PrivateKey, password string) (accounts.Account, error) {
+	return s.importKey(privateKey, nil, password)
+}
+
+// importKey encrypts and stores a key unless it's stored already.
+func (s *MemoryKeyStore) importKey(privateKey *ecdsa.PrivateKey, extKey *extkeys.ExtendedKey, password string) (accounts.Account, error) {
+	key := &keystore.Key{
+		Id:          uuid.NewRandom(),
+		Address:     crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey:  privateKey,
+		ExtendedKey: extKey,
+	}
+	account := memoryAccount(key.Address)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if _, ok := s.keys[key.Address]; ok {
+		return account, nil
+	}
+
+	encrypted, err := keystore.EncryptKey(key, password, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	s.keys[key.Address] = encrypted
+
+	return account, nil
+}
+
+// AccountDecryptedKey returns a key of an account decrypted with a password.
+// keystore.ErrNoMatch is returned for unknown accounts and
+// keystore.ErrDecrypt if the password is wrong. Keys are decrypted
+// for each call, so the caller can zero them.
+func (s *MemoryKeyStore) AccountDecryptedKey(account accounts.Account, password string) (accounts.Account, *keystore.Key, error) {
+	s.mx.RLock()
+	encrypted, ok := s.keys[account.Address]
+	s.mx.RUnlock()
+
+	if !ok {
+		return account, nil, keystore.ErrNoMatch
+	}
+
+	key, err := keystore.DecryptKey(encrypted, password)
+	if err != nil {
+		return account, nil, err
+	}
+
+	return memoryAccount(account.Address), key, nil
+}
+
+// memoryAccount returns an account of MemoryKeyStore.
+func memoryAccount(address gethcommon.Address) accounts.Account {
+	return accounts.Account{
+		Address: address,
+		URL:     accounts.URL{Scheme: MemoryKeyStoreScheme, Path: address.Hex()},
+	}
+}
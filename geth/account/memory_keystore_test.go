@@ -0,0 +1,77 @@
+package account_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryKeyStore(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	diskKeyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	memoryKeyStore := account.NewMemoryKeyStore()
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	masterKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+
+	// keys are imported the same way as into the disk keystore
+	diskAddress, diskPubKey, err := account.ImportExtendedKey(diskKeyStore, masterKey, "password")
+	require.NoError(t, err)
+	address, pubKey, err := account.ImportExtendedKey(memoryKeyStore, masterKey, "password")
+	require.NoError(t, err)
+	require.Equal(t, diskAddress, address)
+	require.Equal(t, diskPubKey, pubKey)
+
+	diskExtKey, err := account.ExportExtendedKey(diskKeyStore, gethcommon.HexToAddress(address), "password")
+	require.NoError(t, err)
+	extKey, err := account.ExportExtendedKey(memoryKeyStore, gethcommon.HexToAddress(address), "password")
+	require.NoError(t, err)
+	require.Equal(t, diskExtKey.String(), extKey.String())
+
+	// importing again returns the same account
+	acc, err := memoryKeyStore.ImportExtendedKey(masterKey, "other password")
+	require.NoError(t, err)
+	require.Equal(t, address, acc.Address.Hex())
+	require.Equal(t, account.MemoryKeyStoreScheme, acc.URL.Scheme)
+
+	// wrong passwords and unknown accounts are rejected
+	_, _, err = memoryKeyStore.AccountDecryptedKey(acc, "other password")
+	require.Equal(t, keystore.ErrDecrypt, err)
+	ok, err := account.VerifyPassword(memoryKeyStore, address, "other password")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, _, err = memoryKeyStore.AccountDecryptedKey(accounts.Account{Address: gethcommon.HexToAddress("0x01")}, "password")
+	require.Equal(t, keystore.ErrNoMatch, err)
+
+	// public keys can't be imported
+	publicKey, err := masterKey.Neuter()
+	require.NoError(t, err)
+	_, _, err = account.ImportExtendedKey(memoryKeyStore, publicKey, "password")
+	require.Error(t, err)
+
+	// child keys can be derived and private keys imported
+	childAddress, _, err := account.DeriveChild(memoryKeyStore, extKey, 0, "password")
+	require.NoError(t, err)
+	diskChildAddress, _, err := account.DeriveChild(diskKeyStore, diskExtKey, 0, "password")
+	require.NoError(t, err)
+	require.Equal(t, diskChildAddress, childAddress)
+
+	privateKeyAddress, _, err := account.ImportPrivateKey(memoryKeyStore, "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318", "password")
+	require.NoError(t, err)
+	ok, err = account.VerifyPassword(memoryKeyStore, privateKeyAddress, "password")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
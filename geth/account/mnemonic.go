@@ -0,0 +1,231 @@
+package account
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/extkeys"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrNoMnemonic is returned when no mnemonic is stored for an account.
+// Accounts imported from private keys or extended keys directly
+// never have a mnemonic.
+var ErrNoMnemonic = errors.New("no mnemonic stored for the account")
+
+const (
+	mnemonicScryptR      = 8
+	mnemonicScryptKeyLen = 32
+	mnemonicSaltLen      = 32
+)
+
+// encryptedMnemonic is a mnemonic encrypted with AES-GCM
+// using a key derived from a password with scrypt.
+type encryptedMnemonic struct {
+	Address    string `json:"address"`
+	Ciphertext []byte `json:"ciphertext"`
+	Nonce      []byte `json:"nonce"`
+	Salt       []byte `json:"salt"`
+	ScryptN    int    `json:"n"`
+	ScryptP    int    `json:"p"`
+}
+
+// MnemonicStore keeps encrypted mnemonics of accounts in a directory.
+type MnemonicStore struct {
+	dir     string
+	scryptN int
+	scryptP int
+}
+
+// NewMnemonicStore returns a MnemonicStore keeping mnemonics in dir.
+// scryptN and scryptP are parameters of the key derivation,
+// like keystore.StandardScryptN and keystore.StandardScryptP.
+func NewMnemonicStore(dir string, scryptN, scryptP int) *MnemonicStore {
+	return &MnemonicStore{
+		dir:     dir,
+		scryptN: scryptN,
+		scryptP: scryptP,
+	}
+}
+
+// ImportMnemonic re-creates a master key from an English BIP-39 mnemonic,
+// imports it into the keystore and stores the mnemonic encrypted with the
+// password, so that it can be exported with ExportMnemonic. If store is nil,
+// the mnemonic isn't stored. An invalid mnemonic is reported as an ImportError
+// of kind ErrInvalidMnemonic, and failures to write keys as ErrKeystoreIO.
+func ImportMnemonic(keyStore AccountKeyStorer, store *MnemonicStore, mnemonic, password string) (address, pubKey string, err error) {
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	if err := validateMnemonic(mn, mnemonic); err != nil {
+		return "", "", &ImportError{Kind: ErrInvalidMnemonic, Err: err}
+	}
+
+	extKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, password), []byte(extkeys.Salt))
+	if err != nil {
+		return "", "", wrapImportError(ErrInvalidMasterKeyCreated)
+	}
+
+	address, pubKey, err = ImportExtendedKey(keyStore, extKey, password)
+	if err != nil {
+		return address, pubKey, err
+	}
+
+	if store == nil {
+		return address, pubKey, nil
+	}
+
+	if err := store.storeMnemonic(address, mnemonic, password); err != nil {
+		return address, pubKey, wrapImportError(err)
+	}
+
+	return address, pubKey, nil
+}
+
+// validateMnemonic checks the word count, words and checksum of an English mnemonic.
+// Errors don't include the words, so that they can be logged safely.
+func validateMnemonic(mn *extkeys.Mnemonic, mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	if n := len(words); n%3 != 0 || n < 12 || n > 24 {
+		return fmt.Errorf("mnemonic must have 12, 15, 18, 21 or 24 words, got %d", n)
+	}
+
+	wordList, err := mn.WordList(extkeys.EnglishLanguage)
+	if err != nil {
+		return err
+	}
+
+	indices := make(map[string]int64, len(wordList))
+	for i, word := range wordList {
+		indices[word] = int64(i)
+	}
+
+	// Each word encodes 11 bits of the entropy followed by its checksum.
+	bits := new(big.Int)
+	for i, word := range words {
+		index, ok := indices[word]
+		if !ok {
+			return fmt.Errorf("mnemonic word %d is not in the word list", i+1)
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(index))
+	}
+
+	checksumLen := uint(len(words) / 3)
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumLen-1)).Uint64()
+
+	entropy := make([]byte, checksumLen*4)
+	entropyBytes := bits.Rsh(bits, checksumLen).Bytes()
+	copy(entropy[len(entropy)-len(entropyBytes):], entropyBytes)
+
+	hash := sha256.Sum256(entropy)
+	if uint64(hash[0]>>(8-checksumLen)) != checksum {
+		return errors.New("mnemonic checksum mismatch")
+	}
+
+	return nil
+}
+
+// ExportMnemonic decrypts and returns the mnemonic an account was imported from.
+// ErrNoMnemonic is returned if no mnemonic is stored for the address
+// and ErrWrongPassword if the password can't decrypt it.
+func (s *MnemonicStore) ExportMnemonic(address, password string) (string, error) {
+	if !gethcommon.IsHexAddress(address) {
+		return "", ErrAddressToAccountMappingFailure
+	}
+
+	data, err := ioutil.ReadFile(s.path(address))
+	if os.IsNotExist(err) {
+		return "", ErrNoMnemonic
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var encrypted encryptedMnemonic
+	if err := json.Unmarshal(data, &encrypted); err != nil {
+		return "", fmt.Errorf("failed to read mnemonic file: %v", err)
+	}
+
+	aead, err := mnemonicCipher(password, encrypted.Salt, encrypted.ScryptN, encrypted.ScryptP)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, []byte(encrypted.Address))
+	if err != nil {
+		return "", ErrWrongPassword
+	}
+
+	return string(plaintext), nil
+}
+
+// storeMnemonic encrypts a mnemonic of an account with a password and saves it.
+func (s *MnemonicStore) storeMnemonic(address, mnemonic, password string) error {
+	salt := make([]byte, mnemonicSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+
+	aead, err := mnemonicCipher(password, salt, s.scryptN, s.scryptP)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	address = gethcommon.HexToAddress(address).Hex()
+	encrypted := encryptedMnemonic{
+		Address:    address,
+		Ciphertext: aead.Seal(nil, nonce, []byte(mnemonic), []byte(address)),
+		Nonce:      nonce,
+		Salt:       salt,
+		ScryptN:    s.scryptN,
+		ScryptP:    s.scryptP,
+	}
+
+	data, err := json.Marshal(encrypted)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path(address), data, 0600)
+}
+
+// path returns a path of a file with the mnemonic of an account.
+func (s *MnemonicStore) path(address string) string {
+	name := strings.ToLower(gethcommon.HexToAddress(address).Hex()[2:]) + ".json"
+	return filepath.Join(s.dir, name)
+}
+
+// mnemonicCipher returns AES-GCM with a key derived from a password.
+func mnemonicCipher(password string, salt []byte, scryptN, scryptP int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(password), salt, scryptN, mnemonicScryptR, scryptP, mnemonicScryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,72 @@
+package account_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportMnemonic(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	store := account.NewMnemonicStore(filepath.Join(keyStoreDir, "mnemonics"), keystore.LightScryptN, keystore.LightScryptP)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	address, _, err := account.ImportMnemonic(keyStore, store, mnemonic, "password")
+	require.NoError(t, err)
+
+	exported, err := store.ExportMnemonic(address, "password")
+	require.NoError(t, err)
+	require.Equal(t, mnemonic, exported)
+
+	_, err = store.ExportMnemonic(address, "wrong password")
+	require.Equal(t, account.ErrWrongPassword, err)
+
+	// no mnemonic is stored for accounts imported from keys
+	_, err = store.ExportMnemonic("0xadaf150b905cf5e6a778e553e15a139b6618bbb7", "password")
+	require.Equal(t, account.ErrNoMnemonic, err)
+}
+
+func TestImportMnemonicValidation(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	valid := []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		strings.Repeat("abandon ", 23) + "art",
+	}
+	for _, mnemonic := range valid {
+		_, _, err := account.ImportMnemonic(keyStore, nil, mnemonic, "password")
+		require.NoError(t, err)
+	}
+
+	invalid := []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",          // word count
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon notaword", // unknown word
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",  // checksum
+	}
+	for _, mnemonic := range invalid {
+		_, _, err := account.ImportMnemonic(keyStore, nil, mnemonic, "password")
+		require.True(t, errors.Is(err, account.ErrInvalidMnemonic), "unexpected error: %v", err)
+	}
+
+	// failures to store the mnemonic are reported as I/O errors
+	notDir := filepath.Join(keyStoreDir, "file")
+	require.NoError(t, ioutil.WriteFile(notDir, nil, 0600))
+	store := account.NewMnemonicStore(filepath.Join(notDir, "mnemonics"), keystore.LightScryptN, keystore.LightScryptP)
+	_, _, err = account.ImportMnemonic(keyStore, store, valid[0], "password")
+	require.True(t, errors.Is(err, account.ErrKeystoreIO), "unexpected error: %v", err)
+}
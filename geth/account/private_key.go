@@ -0,0 +1,82 @@
+package account
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrPrivateKeyImportUnsupported is returned when a key store backend can't import raw private keys.
+var ErrPrivateKeyImportUnsupported = errors.New("key store doesn't support importing private keys")
+
+// privateKeyHexLen is a length of a hex-encoded secp256k1 private key.
+const privateKeyHexLen = 64
+
+// privateKeyImporter is implemented by key stores able to import
+// raw ECDSA keys, like keystore.KeyStore.
+type privateKeyImporter interface {
+	ImportECDSA(privateKey *ecdsa.PrivateKey, password string) (accounts.Account, error)
+}
+
+// ImportPrivateKey imports a hex-encoded secp256k1 private key, e.g. exported
+// from MetaMask or a paper wallet, into a keystore and returns the address
+// and public key of the account. The key may be prefixed with 0x.
+// Keystore errors are wrapped into ImportError.
+func ImportPrivateKey(keyStore AccountKeyStorer, privateKeyHex, password string) (address, pubKey string, err error) {
+	if keyStore == nil {
+		return "", "", ErrNilKeyStore
+	}
+	// Keys are almost never meant to be stored unencrypted.
+	if password == "" {
+		return "", "", ErrEmptyPassword
+	}
+
+	importer, ok := keyStore.(privateKeyImporter)
+	if !ok {
+		return "", "", ErrPrivateKeyImportUnsupported
+	}
+
+	privateKey, err := parsePrivateKey(privateKeyHex)
+	if err != nil {
+		return "", "", &ImportError{Kind: ErrInvalidKey, Err: err}
+	}
+
+	account, err := importer.ImportECDSA(privateKey, password)
+	if err != nil {
+		return "", "", wrapImportError(err)
+	}
+	address = account.Address.Hex()
+
+	// obtain public key to return
+	_, key, err := keyStore.AccountDecryptedKey(account, password)
+	if err != nil {
+		return address, "", wrapImportError(err)
+	}
+	if err := checkKeyAddress(account, key); err != nil {
+		return address, "", err
+	}
+	defer zeroKey(key)
+
+	pubKey = gethcommon.ToHex(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
+
+	return address, pubKey, nil
+}
+
+// parsePrivateKey decodes a hex-encoded private key with an optional 0x prefix.
+func parsePrivateKey(privateKeyHex string) (*ecdsa.PrivateKey, error) {
+	privateKeyHex = strings.TrimSpace(privateKeyHex)
+	if strings.HasPrefix(privateKeyHex, "0x") || strings.HasPrefix(privateKeyHex, "0X") {
+		privateKeyHex = privateKeyHex[2:]
+	}
+
+	if len(privateKeyHex) != privateKeyHexLen {
+		return nil, fmt.Errorf("private key must be %d hex characters long, got %d", privateKeyHexLen, len(privateKeyHex))
+	}
+
+	return crypto.HexToECDSA(privateKeyHex)
+}
@@ -0,0 +1,75 @@
+package account_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPrivateKey(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	privateKeyHex := "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	require.NoError(t, err)
+
+	// the 0x prefix is accepted
+	address, pubKey, err := account.ImportPrivateKey(keyStore, "0x"+privateKeyHex, "password")
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey).Hex(), address)
+	require.Equal(t, gethcommon.ToHex(crypto.FromECDSAPub(&privateKey.PublicKey)), pubKey)
+
+	ok, err := account.VerifyPassword(keyStore, address, "password")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the decrypted key is wiped
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	recording := &recordingKeyStore{KeyStore: keyStore}
+	_, _, err = account.ImportPrivateKey(recording, gethcommon.Bytes2Hex(crypto.FromECDSA(otherKey)), "password")
+	require.NoError(t, err)
+	for _, word := range recording.key.PrivateKey.D.Bits() {
+		require.Zero(t, word)
+	}
+
+	// keys are never stored unencrypted
+	_, _, err = account.ImportPrivateKey(keyStore, privateKeyHex, "")
+	require.Equal(t, account.ErrEmptyPassword, err)
+
+	_, _, err = account.ImportPrivateKey(nil, privateKeyHex, "password")
+	require.Equal(t, account.ErrNilKeyStore, err)
+
+	// keys of a wrong length are rejected
+	_, _, err = account.ImportPrivateKey(keyStore, privateKeyHex[2:], "password")
+	require.True(t, errors.Is(err, account.ErrInvalidKey), "unexpected error: %v", err)
+	require.Contains(t, err.Error(), "64 hex characters")
+
+	// as well as keys which aren't hex-encoded
+	_, _, err = account.ImportPrivateKey(keyStore, "zz"+privateKeyHex[2:], "password")
+	require.True(t, errors.Is(err, account.ErrInvalidKey), "unexpected error: %v", err)
+}
+
+// recordingKeyStore records the last key it decrypted.
+type recordingKeyStore struct {
+	*keystore.KeyStore
+	key *keystore.Key
+}
+
+func (s *recordingKeyStore) AccountDecryptedKey(a accounts.Account, password string) (accounts.Account, *keystore.Key, error) {
+	a, key, err := s.KeyStore.AccountDecryptedKey(a, password)
+	s.key = key
+	return a, key, err
+}
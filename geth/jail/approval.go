@@ -0,0 +1,86 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/status-im/status-go/geth/common"
+)
+
+// userRejectedErrorCode is a JSON-RPC error code of transactions
+// rejected by the approval handler.
+const userRejectedErrorCode = -32000
+
+var (
+	// ErrUserRejected is returned to a dapp when a user rejects a transaction.
+	ErrUserRejected = errors.New("user rejected")
+
+	// ErrInvalidTxRequest is thrown in a cell when a transaction can't be
+	// decoded for the approval handler, so it's not sent unapproved.
+	ErrInvalidTxRequest = errors.New("invalid transaction request")
+)
+
+// TxRequest is a transaction a cell requested to send with eth_sendTransaction.
+type TxRequest common.SendTxArgs
+
+// ApprovalHandler decides whether a transaction requested by a cell
+// should be sent. An error aborts the request and is thrown in the cell.
+type ApprovalHandler func(chatID string, tx TxRequest) (approved bool, err error)
+
+// SetApprovalHandler sets a handler consulted before eth_sendTransaction
+// requests are signed, e.g. to prompt the user. If a transaction is not
+// approved, the whole request fails with "user rejected" error.
+// A nil handler approves all transactions.
+func (j *Jail) SetApprovalHandler(fn ApprovalHandler) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.approvalHandler = fn
+}
+
+func (j *Jail) getApprovalHandler() ApprovalHandler {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	return j.approvalHandler
+}
+
+// approveTransactions consults the approval handler for each eth_sendTransaction
+// request of a raw JSON-RPC payload. It returns false if any of them is rejected
+// and an error if a request can't be decoded for the handler.
+func (j *Jail) approveTransactions(cell *Cell, request string) (bool, error) {
+	handler := j.getApprovalHandler()
+	if handler == nil || cell == nil {
+		return true, nil
+	}
+
+	requests, err := decodeRequests(request)
+	if err != nil {
+		return false, err
+	}
+
+	for _, req := range requests {
+		if req.Method != sendTransactionMethod {
+			continue
+		}
+
+		if len(req.Params) == 0 {
+			return false, ErrInvalidTxRequest
+		}
+
+		var tx TxRequest
+		if err := json.Unmarshal(req.Params[0], &tx); err != nil {
+			return false, ErrInvalidTxRequest
+		}
+
+		approved, err := handler(cell.id, tx)
+		if err != nil {
+			return false, err
+		}
+		if !approved {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
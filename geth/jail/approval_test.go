@@ -0,0 +1,140 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetApprovalHandler(t *testing.T) {
+	var sent int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "eth_sendTransaction" {
+			atomic.AddInt32(&sent, 1)
+		}
+		return "0x0000000000000000000000000000000000000000000000000000000000000001", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.Parse("cell1", "")
+
+	cell, err := jail.Cell("cell1")
+	require.NoError(t, err)
+
+	const sendTxJS = `web3.eth.sendTransaction({
+		from: "0xadaf150b905cf5e6a778e553e15a139b6618bbb7",
+		to: "0x65c01586aa0ce152c2701b2ad1162b8d859a7234",
+		value: "0x10"
+	})`
+
+	var requests []TxRequest
+	jail.SetApprovalHandler(func(chatID string, tx TxRequest) (bool, error) {
+		require.Equal(t, "cell1", chatID)
+		requests = append(requests, tx)
+		return false, nil
+	})
+
+	_, err = cell.Run(sendTxJS)
+	require.EqualError(t, err, "Error: "+ErrUserRejected.Error())
+	require.Equal(t, int32(0), atomic.LoadInt32(&sent))
+
+	require.Len(t, requests, 1)
+	require.Equal(t, gethcommon.HexToAddress("0xadaf150b905cf5e6a778e553e15a139b6618bbb7"), requests[0].From)
+	require.Equal(t, "0x10", requests[0].Value.String())
+
+	// handler's error is thrown in the cell
+	jail.SetApprovalHandler(func(chatID string, tx TxRequest) (bool, error) {
+		return false, errors.New("prompt failed")
+	})
+	_, err = cell.Run(sendTxJS)
+	require.EqualError(t, err, "prompt failed")
+	require.Equal(t, int32(0), atomic.LoadInt32(&sent))
+
+	jail.SetApprovalHandler(func(chatID string, tx TxRequest) (bool, error) {
+		return true, nil
+	})
+	_, err = cell.Run(sendTxJS)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&sent))
+}
+
+func TestApproveInvalidTransactions(t *testing.T) {
+	jail := New(nil)
+	jail.Parse("cell1", "")
+
+	cell, err := jail.cell("cell1")
+	require.NoError(t, err)
+
+	var calls int32
+	jail.SetApprovalHandler(func(chatID string, tx TxRequest) (bool, error) {
+		atomic.AddInt32(&calls, 1)
+		return true, nil
+	})
+
+	// requests which can't be decoded for the handler are not approved
+	approved, err := jail.approveTransactions(cell, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction"`)
+	require.Error(t, err)
+	require.False(t, approved)
+
+	approved, err = jail.approveTransactions(cell, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":["0x10"]}`)
+	require.Equal(t, ErrInvalidTxRequest, err)
+	require.False(t, approved)
+
+	approved, err = jail.approveTransactions(cell, `[
+		{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[{"value":"0x10"}]},
+		{"jsonrpc":"2.0","id":2,"method":"eth_sendTransaction","params":[]}
+	]`)
+	require.Equal(t, ErrInvalidTxRequest, err)
+	require.False(t, approved)
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestPendingApprovalHoldsNoSlot(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.SetMaxConcurrentRPC(1)
+	jail.Parse("cell1", "")
+	jail.Parse("cell2", "")
+
+	prompted := make(chan struct{})
+	answer := make(chan bool)
+	jail.SetApprovalHandler(func(chatID string, tx TxRequest) (bool, error) {
+		close(prompted)
+		return <-answer, nil
+	})
+
+	cell1, err := jail.cell("cell1")
+	require.NoError(t, err)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := jail.sendRPCCall(cell1, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[{"from":"0xadaf150b905cf5e6a778e553e15a139b6618bbb7"}]}`)
+		errc <- err
+	}()
+	<-prompted
+
+	// requests of other cells are sent while the prompt is pending
+	cell2, err := jail.cell("cell2")
+	require.NoError(t, err)
+	response, err := jail.sendRPCCall(cell2, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, "0x1", response.(map[string]interface{})["result"])
+
+	answer <- true
+	require.NoError(t, <-errc)
+}
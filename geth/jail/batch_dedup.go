@@ -0,0 +1,151 @@
+package jail
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// SetBatchDedup enables or disables de-duplication of batches, which is
+// disabled by default. When enabled, requests of a batch calling the same
+// method with the same params are sent to the node once, and each of them
+// gets the response with its own ID. eth_sendTransaction requests are never
+// de-duplicated, as each of them sends a transaction.
+func (j *Jail) SetBatchDedup(enabled bool) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.batchDedup = enabled
+}
+
+// batchDedupEnabled returns true if batches are de-duplicated.
+func (j *Jail) batchDedupEnabled() bool {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	return j.batchDedup
+}
+
+// dedupBatch returns a batch with unique requests of a raw JSON-RPC batch
+// and indexes of the unique requests for each request of the batch.
+// The unique requests get their indexes as IDs. It returns false if
+// the payload is not a batch or has no duplicate requests.
+func dedupBatch(request string) (string, []int, bool) {
+	if !isBatchRequest(request) {
+		return "", nil, false
+	}
+
+	var msgs []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(request), &msgs); err != nil {
+		return "", nil, false
+	}
+
+	var (
+		unique  []map[string]json.RawMessage
+		indexes = make(map[string]int)
+		slots   = make([]int, len(msgs))
+	)
+	for i, msg := range msgs {
+		key, ok := dedupKey(msg)
+		if !ok {
+			return "", nil, false
+		}
+
+		index, ok := indexes[key]
+		if !ok {
+			index = len(unique)
+			indexes[key] = index
+
+			uniqueMsg := make(map[string]json.RawMessage, len(msg))
+			for k, v := range msg {
+				uniqueMsg[k] = v
+			}
+			uniqueMsg["id"] = json.RawMessage(strconv.Itoa(index))
+			unique = append(unique, uniqueMsg)
+		}
+		slots[i] = index
+	}
+
+	if len(unique) == len(msgs) {
+		return "", nil, false
+	}
+
+	data, err := json.Marshal(unique)
+	if err != nil {
+		return "", nil, false
+	}
+
+	return string(data), slots, true
+}
+
+// dedupKey returns a key of a request, which is the same for requests
+// calling the same method with the same params. It returns false if
+// the request must be sent even if it's a duplicate.
+func dedupKey(msg map[string]json.RawMessage) (string, bool) {
+	if len(msg["id"]) == 0 {
+		return "", false
+	}
+
+	method := requestMethod(msg)
+	if method == "" || method == sendTransactionMethod {
+		return "", false
+	}
+
+	// Params are re-encoded, so that formatting and the order of keys don't matter.
+	var params interface{}
+	if raw := msg["params"]; len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return "", false
+		}
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+
+	return method + "\x00" + string(data), true
+}
+
+// expandBatchResponse maps responses to a batch returned by dedupBatch
+// to the requests of the original batch, restoring their IDs.
+// The raw response is returned unchanged if it can't be decoded.
+func expandBatchResponse(request string, slots []int, rawResponse string) string {
+	var msgs []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(request), &msgs); err != nil || len(msgs) != len(slots) {
+		return rawResponse
+	}
+
+	var responses []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(rawResponse), &responses); err != nil {
+		return rawResponse
+	}
+
+	byIndex := make(map[int]map[string]json.RawMessage, len(responses))
+	for _, response := range responses {
+		index, err := strconv.Atoi(string(response["id"]))
+		if err != nil {
+			return rawResponse
+		}
+		byIndex[index] = response
+	}
+
+	expanded := make([]map[string]json.RawMessage, len(slots))
+	for i, index := range slots {
+		response, ok := byIndex[index]
+		if !ok {
+			return rawResponse
+		}
+
+		expanded[i] = make(map[string]json.RawMessage, len(response))
+		for k, v := range response {
+			expanded[i][k] = v
+		}
+		expanded[i]["id"] = msgs[i]["id"]
+	}
+
+	data, err := json.Marshal(expanded)
+	if err != nil {
+		return rawResponse
+	}
+
+	return string(data)
+}
@@ -0,0 +1,64 @@
+package jail
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchDedup(t *testing.T) {
+	var calls int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "0x01", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	// formatting of params doesn't matter
+	request := `[
+		{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x01","data":"0x02"},"latest"]},
+		{"jsonrpc":"2.0","id":"a","method":"eth_call","params":[{"data":"0x02","to":"0x01"},"latest"]},
+		{"jsonrpc":"2.0","id":3,"method":"eth_call","params":[{"to":"0x01","data":"0x02"}, "latest"]}
+	]`
+	expected := `[
+		{"jsonrpc":"2.0","id":1,"result":"0x01"},
+		{"jsonrpc":"2.0","id":"a","result":"0x01"},
+		{"jsonrpc":"2.0","id":3,"result":"0x01"}
+	]`
+
+	// batches are not de-duplicated by default
+	response, err := jail.SendRaw(request)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, response)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	jail.SetBatchDedup(true)
+	atomic.StoreInt32(&calls, 0)
+
+	response, err = jail.SendRaw(request)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, response)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// different requests are sent as usual
+	atomic.StoreInt32(&calls, 0)
+	response, err = jail.SendRaw(`[
+		{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x01"},"latest"]},
+		{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{"to":"0x01"},"pending"]},
+		{"jsonrpc":"2.0","id":3,"method":"eth_call","params":[{"to":"0x01"},"latest"]}
+	]`)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"jsonrpc":"2.0","id":1,"result":"0x01"},
+		{"jsonrpc":"2.0","id":2,"result":"0x01"},
+		{"jsonrpc":"2.0","id":3,"result":"0x01"}
+	]`, response)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
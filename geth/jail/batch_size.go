@@ -0,0 +1,38 @@
+package jail
+
+import "fmt"
+
+// DefaultMaxBatchSize is a default maximum number of requests
+// in a JSON-RPC batch sent by a cell.
+const DefaultMaxBatchSize = 50
+
+// SetMaxBatchSize sets a maximum number of requests in a JSON-RPC batch.
+// Larger batches are rejected as a whole with a single -32600 error response
+// and none of their requests are executed. Zero removes the limit.
+func (j *Jail) SetMaxBatchSize(size int) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.maxBatchSize = size
+}
+
+// oversizedBatchResponse returns an error response if a raw JSON-RPC payload
+// is a batch with more requests than allowed.
+func (j *Jail) oversizedBatchResponse(request string) (interface{}, bool) {
+	j.settingsMx.RLock()
+	maxSize := j.maxBatchSize
+	j.settingsMx.RUnlock()
+
+	if maxSize <= 0 || !isBatchRequest(request) {
+		return nil, false
+	}
+
+	requests, err := decodeRequests(request)
+	if err != nil || len(requests) <= maxSize {
+		return nil, false
+	}
+
+	err = fmt.Errorf("%s: batch of %d requests exceeds the limit of %d", errInvalidRequest, len(requests), maxSize)
+	response, err := newErrorResponses("", invalidRequestCode, err)
+	return response, err == nil
+}
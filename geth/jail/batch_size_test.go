@@ -0,0 +1,55 @@
+package jail
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMaxBatchSize(t *testing.T) {
+	var requests int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&requests, 1)
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	batch := func(size int) string {
+		requests := make([]string, size)
+		for i := range requests {
+			requests[i] = `{"jsonrpc":"2.0","id":` + strconv.Itoa(i) + `,"method":"eth_blockNumber","params":[]}`
+		}
+		return "[" + strings.Join(requests, ",") + "]"
+	}
+
+	response, err := jail.sendRPCCall(nil, batch(100))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      float64(0),
+		"error": map[string]interface{}{
+			"code":    float64(-32600),
+			"message": "invalid request: batch of 100 requests exceeds the limit of 50",
+		},
+	}, response)
+	require.Equal(t, int32(0), atomic.LoadInt32(&requests))
+
+	response, err = jail.sendRPCCall(nil, batch(DefaultMaxBatchSize))
+	require.NoError(t, err)
+	require.Len(t, response, DefaultMaxBatchSize)
+	require.Equal(t, int32(DefaultMaxBatchSize), atomic.LoadInt32(&requests))
+
+	jail.SetMaxBatchSize(0)
+	response, err = jail.sendRPCCall(nil, batch(100))
+	require.NoError(t, err)
+	require.Len(t, response, 100)
+}
@@ -0,0 +1,43 @@
+package jail
+
+import "fmt"
+
+// RegisterBundle registers a named JavaScript bundle, e.g. a helper library
+// needed by some DApps only, which can be included with ParseWithBundles.
+// Registering a bundle with the same name replaces it.
+func (j *Jail) RegisterBundle(name, js string) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	if j.bundles == nil {
+		j.bundles = make(map[string]string)
+	}
+	j.bundles[name] = js
+}
+
+// ParseWithBundles works like Parse, but the given bundles registered
+// with RegisterBundle are run in the requested order after the base JS
+// and before the code. Without bundles, it's equivalent to Parse.
+func (j *Jail) ParseWithBundles(chatID, code string, bundles ...string) string {
+	opts := defaultParseOptions(code)
+	opts.Bundles = bundles
+
+	return j.ParseWithOptions(chatID, opts)
+}
+
+// bundleScripts returns the code of bundles with the given names.
+func (j *Jail) bundleScripts(names []string) ([]string, error) {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	scripts := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		js, ok := j.bundles[name]
+		if !ok {
+			return nil, fmt.Errorf("bundle '%s' not found", name)
+		}
+		scripts = append(scripts, js)
+	}
+
+	return scripts, nil
+}
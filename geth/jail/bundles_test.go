@@ -0,0 +1,36 @@
+package jail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithBundles(t *testing.T) {
+	jail := New(nil)
+	defer jail.Stop()
+
+	jail.RegisterBundle("tokens", `var tokens = {symbol: function () { return "SNT"; }};`)
+	jail.RegisterBundle("a", `var last = "a";`)
+	jail.RegisterBundle("b", `var last = "b";`)
+
+	// globals defined by bundles are visible in the cell
+	response := jail.ParseWithBundles("cell1", `var _status_catalog = {symbol: tokens.symbol()};`, "tokens")
+	require.Equal(t, `{"result": {"symbol":"SNT"}}`, response)
+
+	// bundles run in the requested order
+	response = jail.ParseWithBundles("cell2", `var _status_catalog = {last: last};`, "b", "a")
+	require.Equal(t, `{"result": {"last":"a"}}`, response)
+	response = jail.ParseWithBundles("cell2", `var _status_catalog = {last: last};`, "a", "b")
+	require.Equal(t, `{"result": {"last":"b"}}`, response)
+
+	// other cells don't get the bundles
+	response = jail.ParseWithBundles("cell3", `var _status_catalog = {tokens: typeof tokens};`)
+	require.Equal(t, `{"result": {"tokens":"undefined"}}`, response)
+
+	// unknown bundles are rejected
+	response = jail.ParseWithBundles("cell4", `var _status_catalog = {};`, "unknown")
+	require.Equal(t, `{"error":"bundle 'unknown' not found"}`, response)
+	_, err := jail.cell("cell4")
+	require.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package jail
+
+import "context"
+
+// CallContext works like Call, but RPC requests sent by the called command
+// are aborted when ctx is cancelled, including requests waiting for a free
+// slot (see SetMaxConcurrentRPC), so that the host can abandon a long-running
+// call, e.g. when a user navigates away from a DApp. The call also gives up
+// waiting for another call of the cell to finish.
+// Async requests, whose callbacks can run after the call returns, are not aborted.
+func (j *Jail) CallContext(ctx context.Context, chatID, commandPath, args string) string {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	value, err := j.callCell(cell, commandPath, args, callOptions{ctx: ctx})
+	if err != nil {
+		return j.newScriptErrorResponse(err)
+	}
+
+	return newJailResultResponse(value.String())
+}
+
+// setCallContext sets a context of the call in progress. Nil resets it.
+func (c *Cell) setCallContext(ctx context.Context) {
+	c.callCtxMx.Lock()
+	defer c.callCtxMx.Unlock()
+
+	c.callCtx = ctx
+}
+
+// callContext returns a context of the call in progress, if any.
+func (c *Cell) callContext() context.Context {
+	c.callCtxMx.Lock()
+	defer c.callCtxMx.Unlock()
+
+	return c.callCtx
+}
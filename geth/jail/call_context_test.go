@@ -0,0 +1,90 @@
+package jail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallContext(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	// The node hangs until the request is cancelled.
+	started := make(chan struct{}, 1)
+	provider.rpcClient.RegisterHandler("eth_blockNumber", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	jail := New(provider)
+	defer jail.Stop()
+
+	jail.SetMaxConcurrentRPC(1)
+
+	code := `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return web3.eth.blockNumber;
+		}
+	`
+	require.NotContains(t, jail.Parse("cell1", code), "error")
+	require.NotContains(t, jail.Parse("cell2", code), "error")
+
+	// a cancelled context aborts the call before it starts
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, `{"error":"context canceled"}`, jail.CallContext(ctx, "cell1", `["commands", "blockNumber"]`, `{}`))
+
+	// cancelling aborts a request in progress
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	done1 := make(chan string, 1)
+	go func() {
+		done1 <- jail.CallContext(ctx1, "cell1", `["commands", "blockNumber"]`, `{}`)
+	}()
+	<-started
+
+	// as well as a request waiting for a free slot
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	done2 := make(chan string, 1)
+	go func() {
+		done2 <- jail.CallContext(ctx2, "cell2", `["commands", "blockNumber"]`, `{}`)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for jail.rpcScheduler.waiting() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 1, jail.rpcScheduler.waiting())
+
+	cancel2()
+	select {
+	case response := <-done2:
+		require.Contains(t, response, "context canceled")
+	case <-time.After(time.Second):
+		t.Fatal("waiting call was not aborted")
+	}
+	require.Equal(t, 0, jail.rpcScheduler.waiting())
+
+	cancel1()
+	select {
+	case response := <-done1:
+		require.Contains(t, response, "context canceled")
+	case <-time.After(time.Second):
+		t.Fatal("call was not aborted")
+	}
+
+	// all slots are released
+	jail.rpcScheduler.mx.Lock()
+	active := jail.rpcScheduler.active
+	jail.rpcScheduler.mx.Unlock()
+	require.Equal(t, 0, active)
+}
@@ -0,0 +1,141 @@
+package jail
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultCellWaitTimeout is a default time a call waits
+// for another call of the same cell to finish.
+const DefaultCellWaitTimeout = 60 * time.Second
+
+// ErrCellWaitTimeout is returned when a call times out waiting
+// for another call of the same cell to finish.
+var ErrCellWaitTimeout = errors.New("timed out waiting for cell")
+
+// SetCellWaitTimeout sets how long a call waits for another call
+// of the same cell to finish before it fails with ErrCellWaitTimeout.
+// Zero disables the timeout. See DefaultCellWaitTimeout.
+func (j *Jail) SetCellWaitTimeout(timeout time.Duration) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.cellWaitTimeout = timeout
+}
+
+// lockCell waits for other calls of a cell to finish, which is
+// given up on when ctx is done or the cell wait timeout expires.
+func (j *Jail) lockCell(ctx context.Context, cell *Cell) error {
+	j.settingsMx.RLock()
+	timeout := j.cellWaitTimeout
+	j.settingsMx.RUnlock()
+
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := cell.lockCallsContext(waitCtx); err != nil {
+		if ctx.Err() == nil {
+			return ErrCellWaitTimeout
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetCallTimeout sets a timeout of RPC requests sent by cells.
+// Zero disables the timeout, which is the default.
+func (j *Jail) SetCallTimeout(timeout time.Duration) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.callTimeout = timeout
+}
+
+// SetMethodTimeout overrides the timeout set with SetCallTimeout
+// for requests of a given method, e.g. a longer one for eth_getLogs.
+// A negative timeout removes the override.
+func (j *Jail) SetMethodTimeout(method string, timeout time.Duration) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	if timeout < 0 {
+		delete(j.methodTimeouts, method)
+		return
+	}
+
+	if j.methodTimeouts == nil {
+		j.methodTimeouts = make(map[string]time.Duration)
+	}
+	j.methodTimeouts[method] = timeout
+}
+
+// requestTimeout returns a timeout of a raw JSON-RPC payload.
+// A batch gets the longest timeout of its requests, and zero
+// means that the request doesn't time out.
+func (j *Jail) requestTimeout(request string) time.Duration {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	if len(j.methodTimeouts) == 0 {
+		return j.callTimeout
+	}
+
+	requests, err := decodeRequests(request)
+	if err != nil {
+		return j.callTimeout
+	}
+
+	var timeout time.Duration
+	for i, req := range requests {
+		t, ok := j.methodTimeouts[req.Method]
+		if !ok {
+			t = j.callTimeout
+		}
+		if t == 0 {
+			return 0
+		}
+		if i == 0 || t > timeout {
+			timeout = t
+		}
+	}
+
+	return timeout
+}
+
+// requestContext returns a context of a raw JSON-RPC payload derived
+// from ctx with its timeout applied. If the request is sent by a cell,
+// the context is cancelled when the cell is stopped as well.
+func (j *Jail) requestContext(ctx context.Context, cell *Cell, request string) (context.Context, context.CancelFunc) {
+	parent := ctx
+	if cell != nil {
+		parent = cell.ctx
+	}
+
+	reqCtx, cancel := context.WithCancel(parent)
+	if parent != ctx && ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				cancel()
+			case <-reqCtx.Done():
+			}
+		}()
+	}
+
+	timeout := j.requestTimeout(request)
+	if timeout == 0 {
+		return reqCtx, cancel
+	}
+
+	reqCtx, cancelTimeout := context.WithTimeout(reqCtx, timeout)
+	return reqCtx, func() {
+		cancelTimeout()
+		cancel()
+	}
+}
@@ -0,0 +1,48 @@
+package jail
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCallTimeout(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "eth_getLogs" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	const getLogsRequest = `{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{}]}`
+
+	responseError := func(request string) interface{} {
+		response, err := jail.sendRPCCall(nil, request)
+		require.NoError(t, err)
+		return response.(map[string]interface{})["error"]
+	}
+
+	// no timeout by default
+	require.Nil(t, responseError(getLogsRequest))
+
+	jail.SetCallTimeout(50 * time.Millisecond)
+	rpcErr, ok := responseError(getLogsRequest).(map[string]interface{})
+	require.True(t, ok)
+	require.Contains(t, rpcErr["message"], "deadline exceeded")
+	require.Nil(t, responseError(testBlockNumberRequest))
+
+	// the method timeout overrides the default one
+	jail.SetMethodTimeout("eth_getLogs", time.Second)
+	require.Nil(t, responseError(getLogsRequest))
+
+	jail.SetMethodTimeout("eth_getLogs", -1)
+	require.NotNil(t, responseError(getLogsRequest))
+}
@@ -0,0 +1,131 @@
+package jail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// waitForGoroutines waits until the number of goroutines drops to n.
+func waitForGoroutines(n int) int {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		current := runtime.NumGoroutine()
+		if current <= n || time.Now().After(deadline) {
+			return current
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRPCRequestsAreCancelled(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	// The node hangs until the request is cancelled.
+	provider.rpcClient.RegisterHandler("eth_blockNumber", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	jail := New(provider)
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return web3.eth.blockNumber;
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	baseline := runtime.NumGoroutine()
+
+	// a timed out request returns an error
+	jail.SetCallTimeout(50 * time.Millisecond)
+	response = jail.Call("cell1", `["commands", "blockNumber"]`, `{}`)
+	require.Contains(t, response, "deadline exceeded")
+	require.True(t, waitForGoroutines(baseline) <= baseline)
+
+	// removing a cell aborts its request in progress
+	jail.SetCallTimeout(0)
+	done := make(chan string)
+	go func() {
+		done <- jail.Call("cell1", `["commands", "blockNumber"]`, `{}`)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, busy, err := jail.CellInfo("cell1")
+		require.NoError(t, err)
+		if busy || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.NoError(t, jail.RemoveCell("cell1"))
+	select {
+	case response := <-done:
+		require.Contains(t, response, "context canceled")
+	case <-time.After(time.Second):
+		t.Fatal("call was not aborted")
+	}
+}
+
+func TestStopAbortsCalls(t *testing.T) {
+	// a jail which was never used can be stopped
+	var jail Jail
+	jail.Stop()
+
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	provider.rpcClient.RegisterHandler("eth_blockNumber", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	j := New(provider)
+	response := j.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return web3.eth.blockNumber;
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	done := make(chan string, 1)
+	go func() {
+		done <- j.Call("cell1", `["commands", "blockNumber"]`, `{}`)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, busy, err := j.CellInfo("cell1")
+		require.NoError(t, err)
+		if busy || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the call in progress is aborted
+	j.Stop()
+	select {
+	case response := <-done:
+		require.Contains(t, response, "context canceled")
+	case <-time.After(time.Second):
+		t.Fatal("call was not aborted")
+	}
+	require.Empty(t, j.Cells())
+}
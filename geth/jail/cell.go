@@ -3,9 +3,13 @@ package jail
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/jail/internal/fetch"
 	"github.com/status-im/status-go/geth/jail/internal/loop"
 	"github.com/status-im/status-go/geth/jail/internal/loop/looptask"
@@ -15,9 +19,46 @@ import (
 
 // Cell represents a single jail cell, which is basically a JavaScript VM.
 type Cell struct {
+	// callWait is total time in nanoseconds calls waited for callSem. It's accessed
+	// atomically, so it goes first to be 64-bit aligned on 32-bit platforms.
+	callWait int64
+
 	*vm.VM
-	id     string
-	cancel context.CancelFunc
+	id        string
+	ctx       context.Context // cancelled when the cell is stopped
+	cancel    context.CancelFunc
+	createdAt time.Time // set by the jail with its clock, see CellUptime
+
+	groupMx sync.RWMutex
+	grp     *cellGroup
+
+	callSem      chan struct{} // holds a token while a call with options is in progress
+	priority     int32         // 1 if a high-priority call is in progress
+	calls        int32         // number of calls in progress or waiting for callSem
+	callTimeouts int32         // number of calls which timed out waiting for callSem
+
+	eventsMx sync.Mutex
+	events   *[]string // collects events emitted during a call
+
+	callCtxMx sync.Mutex
+	callCtx   context.Context // aborts sync RPC requests of a call in progress
+	callOwner uint64          // ID of the goroutine running a call, see calledBy
+
+	scriptMx   sync.Mutex
+	scriptHash string // hash of the code and bundles run by Parse, see Snapshot
+
+	disabled  int32 // 1 if the cell is disabled
+	rpcFailed int32 // 1 if a sync RPC request failed due to a transport failure
+
+	rateMx      sync.Mutex
+	rateBuckets map[string]*tokenBucket // rate limits of methods
+
+	recordedMx sync.Mutex
+	recorded   []common.RPCCall // requests sent in the dry-run mode
+
+	subsMx    sync.Mutex
+	subs      map[string]*gethrpc.ClientSubscription // subscriptions by IDs
+	lastSubID uint64
 
 	loop        *loop.Loop
 	loopStopped chan struct{}
@@ -40,7 +81,9 @@ func NewCell(id string) (*Cell, error) {
 	cell := Cell{
 		VM:          vm,
 		id:          id,
+		ctx:         ctx,
 		cancel:      cancel,
+		callSem:     make(chan struct{}, 1),
 		loop:        lo,
 		loopStopped: loopStopped,
 	}
@@ -70,6 +113,71 @@ func registerVMHandlers(vm *vm.VM, lo *loop.Loop) error {
 	return fetch.Define(vm, lo)
 }
 
+// enabled returns true if the cell is not disabled.
+func (c *Cell) enabled() bool {
+	return atomic.LoadInt32(&c.disabled) == 0
+}
+
+// setEnabled enables or disables the cell.
+func (c *Cell) setEnabled(enabled bool) {
+	var disabled int32
+	if !enabled {
+		disabled = 1
+	}
+	atomic.StoreInt32(&c.disabled, disabled)
+}
+
+// busy returns true if a call of the cell is in progress.
+func (c *Cell) busy() bool {
+	return atomic.LoadInt32(&c.calls) > 0
+}
+
+// lockCalls waits for a call in progress to finish and prevents
+// other calls from starting until unlockCalls is called.
+func (c *Cell) lockCalls() {
+	c.callSem <- struct{}{}
+}
+
+// lockCallsContext works like lockCalls, but it gives up when ctx is done.
+// The time spent waiting and timeouts are recorded, see Jail.CellStats.
+func (c *Cell) lockCallsContext(ctx context.Context) error {
+	start := time.Now()
+	defer func() {
+		atomic.AddInt64(&c.callWait, int64(time.Since(start)))
+	}()
+
+	select {
+	case c.callSem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			atomic.AddInt32(&c.callTimeouts, 1)
+		}
+		return ctx.Err()
+	}
+}
+
+// unlockCalls allows other calls to start.
+func (c *Cell) unlockCalls() {
+	<-c.callSem
+}
+
+// group returns a group the cell belongs to or nil.
+func (c *Cell) group() *cellGroup {
+	c.groupMx.RLock()
+	defer c.groupMx.RUnlock()
+
+	return c.grp
+}
+
+// setGroup puts the cell into a group. Nil removes the cell from its group.
+func (c *Cell) setGroup(group *cellGroup) {
+	c.groupMx.Lock()
+	defer c.groupMx.Unlock()
+
+	c.grp = group
+}
+
 // Stop halts event loop associated with cell.
 func (c *Cell) Stop() error {
 	c.cancel()
@@ -0,0 +1,39 @@
+package jail
+
+import (
+	"time"
+
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// SetClientAcquireRetry makes the jail obtain the RPC client from the provider
+// again, up to the given number of attempts, if the provider returns nil,
+// e.g. while the node is being restarted to switch networks. The delay
+// before the first retry is backoff and it doubles with each attempt.
+// One attempt, which is the default, disables retries.
+func (j *Jail) SetClientAcquireRetry(attempts int, backoff time.Duration) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.clientAcquireAttempts = attempts
+	j.clientAcquireBackoff = backoff
+}
+
+// acquireClient obtains a client from the provider,
+// retrying as configured with SetClientAcquireRetry.
+func (j *Jail) acquireClient(provider RPCClientProvider) *rpc.Client {
+	j.settingsMx.RLock()
+	attempts := j.clientAcquireAttempts
+	backoff := j.clientAcquireBackoff
+	j.settingsMx.RUnlock()
+
+	client := provider.RPCClient()
+	for attempt := 1; client == nil && attempt < attempts; attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+
+		client = provider.RPCClient()
+	}
+
+	return client
+}
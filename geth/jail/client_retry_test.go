@@ -0,0 +1,53 @@
+package jail
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// restartingRPCClientProvider returns nil a number of times, like a node being restarted.
+type restartingRPCClientProvider struct {
+	client   *rpc.Client
+	failures int32
+	calls    int32
+}
+
+func (p *restartingRPCClientProvider) RPCClient() *rpc.Client {
+	if atomic.AddInt32(&p.calls, 1) <= p.failures {
+		return nil
+	}
+	return p.client
+}
+
+func TestSetClientAcquireRetry(t *testing.T) {
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	require.NoError(t, err)
+
+	// no retries by default
+	provider := &restartingRPCClientProvider{client: client, failures: 2}
+	jail := New(provider)
+	require.Nil(t, jail.RPCClient())
+	require.Equal(t, int32(1), atomic.LoadInt32(&provider.calls))
+
+	provider = &restartingRPCClientProvider{client: client, failures: 2}
+	jail = New(provider)
+	jail.SetClientAcquireRetry(3, 10*time.Millisecond)
+
+	start := time.Now()
+	require.Equal(t, client, jail.RPCClient())
+	require.Equal(t, int32(3), atomic.LoadInt32(&provider.calls))
+	// the backoff doubles: 10ms + 20ms
+	require.True(t, time.Since(start) >= 30*time.Millisecond)
+
+	// retries are given up after the number of attempts
+	provider = &restartingRPCClientProvider{client: client, failures: 5}
+	jail = New(provider)
+	jail.SetClientAcquireRetry(3, time.Millisecond)
+	require.Nil(t, jail.RPCClient())
+	require.Equal(t, int32(3), atomic.LoadInt32(&provider.calls))
+}
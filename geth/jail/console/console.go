@@ -1,6 +1,7 @@
 package console
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -33,6 +34,36 @@ func formatForConsole(argumentList []otto.Value) string {
 	return strings.Join(output, " ")
 }
 
+// Format formats giving otto.Values like console.log does in browsers:
+// strings are written as they are and other values are marshalled to JSON.
+func Format(argumentList []otto.Value) string {
+	output := make([]string, 0, len(argumentList))
+	for _, argument := range argumentList {
+		output = append(output, formatValue(argument))
+	}
+	return strings.Join(output, " ")
+}
+
+// formatValue formats a single otto.Value, falling back to its string
+// representation if it can't be marshalled.
+func formatValue(value otto.Value) string {
+	if value.IsString() || value.IsUndefined() || value.IsFunction() {
+		return value.String()
+	}
+
+	exported, err := value.Export()
+	if err != nil {
+		return value.String()
+	}
+
+	data, err := json.Marshal(exported)
+	if err != nil {
+		return value.String()
+	}
+
+	return string(data)
+}
+
 // convertArgs attempts to convert otto.Values into proper go types else
 // uses original.
 func convertArgs(argumentList []otto.Value) []interface{} {
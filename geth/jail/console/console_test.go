@@ -92,3 +92,19 @@ func (s *ConsoleTestSuite) TestObjectLogging() {
 	require.NoError(err)
 	require.NotEmpty(&customWriter)
 }
+
+// TestFormat validates formatting of values logged from javascript.
+func (s *ConsoleTestSuite) TestFormat() {
+	require := s.Require()
+
+	var formatted string
+	err := s.vm.Set("format", func(fn otto.FunctionCall) otto.Value {
+		formatted = console.Format(fn.ArgumentList)
+		return otto.UndefinedValue()
+	})
+	require.NoError(err)
+
+	_, err = s.vm.Run(`format("text", 1.5, true, null, undefined, [1, "a"], {name: "bob"})`)
+	require.NoError(err)
+	require.Equal(`text 1.5 true null undefined [1,"a"] {"name":"bob"}`, formatted)
+}
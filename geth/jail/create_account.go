@@ -0,0 +1,117 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/geth/account"
+)
+
+// createAccountMethod is a JSON-RPC method creating an account, handled by the jail.
+const createAccountMethod = "status_createAccount"
+
+// createAccountErrorCode is a JSON-RPC error code of failed status_createAccount requests.
+const createAccountErrorCode = -32000
+
+var (
+	// ErrAccountCreationDisabled is returned to cells calling status_createAccount
+	// unless it's enabled with EnableAccountCreation.
+	ErrAccountCreationDisabled = errors.New("account creation is disabled")
+
+	// ErrCreateAccountBatch is returned when status_createAccount is called in a batch.
+	ErrCreateAccountBatch = errors.New("status_createAccount can't be called in a batch")
+
+	// ErrNoAccountKeyStore is returned when the RPC client provider of a jail
+	// doesn't provide an account keystore.
+	ErrNoAccountKeyStore = errors.New("account keystore is not available")
+)
+
+// accountKeyStoreProvider is implemented by RPC client providers
+// which provide an account keystore, like common.NodeManager.
+type accountKeyStoreProvider interface {
+	AccountKeyStore() (*keystore.KeyStore, error)
+}
+
+// EnableAccountCreation enables or disables the status_createAccount method,
+// which is disabled by default. The method takes a password of the account
+// and returns {"address": ..., "pubKey": ...} of a new account created with
+// account.CreateAccount in the keystore of the RPC client provider.
+// Cells can't be trusted with the mnemonic of the account and the jail
+// has no way to show it to the user, so the mnemonic is discarded and
+// such accounts can only be backed up by exporting their keys.
+// Like any other method, it can be blocked for a group of cells with
+// CellConfig.BlockedMethods, and it's not recorded in the dry-run mode.
+func (j *Jail) EnableAccountCreation(enabled bool) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.accountCreation = enabled
+}
+
+// createAccountResponse handles status_createAccount requests. The requests are
+// never sent to the node, so that passwords don't leave the jail. It returns
+// false if a raw JSON-RPC payload doesn't call status_createAccount.
+func (j *Jail) createAccountResponse(request string) (interface{}, bool) {
+	if !callsMethod(request, createAccountMethod) {
+		return nil, false
+	}
+
+	req, ok := singleRequest(request)
+	if !ok {
+		response, err := newErrorResponses(request, createAccountErrorCode, ErrCreateAccountBatch)
+		return response, err == nil
+	}
+
+	result, err := j.createAccount(req.Params)
+	if err != nil {
+		response, err := newErrorResponses(request, createAccountErrorCode, err)
+		return response, err == nil
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      decodeID(req.ID),
+		"result":  result,
+	}, true
+}
+
+// createAccount creates an account with a password passed in params.
+func (j *Jail) createAccount(params []json.RawMessage) (map[string]interface{}, error) {
+	j.settingsMx.RLock()
+	enabled := j.accountCreation
+	j.settingsMx.RUnlock()
+
+	if !enabled {
+		return nil, ErrAccountCreationDisabled
+	}
+
+	var password string
+	if len(params) > 0 {
+		if err := json.Unmarshal(params[0], &password); err != nil {
+			return nil, errors.New("password must be a string")
+		}
+	}
+
+	j.clientMx.Lock()
+	provider, ok := j.rpcClientProvider.(accountKeyStoreProvider)
+	j.clientMx.Unlock()
+	if !ok {
+		return nil, ErrNoAccountKeyStore
+	}
+
+	keyStore, err := provider.AccountKeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	address, pubKey, _, err := account.CreateAccount(keyStore, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"address": address,
+		"pubKey":  pubKey,
+	}, nil
+}
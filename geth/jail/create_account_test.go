@@ -0,0 +1,83 @@
+package jail
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/status-im/status-go/geth/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// testAccountKeyStoreProvider provides an account keystore but no RPC client.
+type testAccountKeyStoreProvider struct {
+	keyStore *keystore.KeyStore
+}
+
+func (p testAccountKeyStoreProvider) RPCClient() *rpc.Client {
+	return nil
+}
+
+func (p testAccountKeyStoreProvider) AccountKeyStore() (*keystore.KeyStore, error) {
+	return p.keyStore, nil
+}
+
+func TestCreateAccount(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-jail-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	jail := New(testAccountKeyStoreProvider{keyStore})
+	defer jail.Stop()
+
+	jail.Parse("cell1", `var _status_catalog = {};`)
+
+	// account creation is disabled by default
+	value := jail.Execute("cell1", `jeth.send({jsonrpc: "2.0", id: 1, method: "status_createAccount", params: ["password"]}).error.message`)
+	require.Equal(t, ErrAccountCreationDisabled.Error(), value)
+
+	jail.EnableAccountCreation(true)
+
+	value = jail.Execute("cell1", `var account = jeth.send({jsonrpc: "2.0", id: 2, method: "status_createAccount", params: ["password"]}).result;
+		account.address + " " + account.pubKey`)
+	require.Regexp(t, `^0x[0-9a-fA-F]{40} 0x04[0-9a-f]{128}$`, value)
+
+	address := jail.Execute("cell1", `account.address`)
+	ok, err := account.VerifyPassword(keyStore, address, "password")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// keys are never stored unencrypted
+	value = jail.Execute("cell1", `jeth.send({jsonrpc: "2.0", id: 3, method: "status_createAccount", params: []}).error.message`)
+	require.Equal(t, account.ErrEmptyPassword.Error(), value)
+
+	// the method can't be batched with other requests
+	value = jail.Execute("cell1", `jeth.send([{jsonrpc: "2.0", id: 4, method: "status_createAccount", params: ["password"]}])[0].error.message`)
+	require.Equal(t, ErrCreateAccountBatch.Error(), value)
+
+	// the password is never recorded
+	jail.SetDryRun(true)
+	value = jail.Execute("cell1", `jeth.send({jsonrpc: "2.0", id: 5, method: "status_createAccount", params: ["password"]}).result.address`)
+	require.Regexp(t, `^0x[0-9a-fA-F]{40}$`, value)
+	require.Empty(t, jail.RecordedCalls("cell1"))
+	jail.SetDryRun(false)
+
+	// the method can be blocked for a group of cells
+	jail.CreateGroup("restricted", CellConfig{BlockedMethods: []string{"status_createAccount"}})
+	jail.ParseInGroup("cell2", "restricted", `var _status_catalog = {};`)
+	value = jail.Execute("cell2", `jeth.send({jsonrpc: "2.0", id: 6, method: "status_createAccount", params: ["password"]}).error.message`)
+	require.Equal(t, ErrMethodNotAllowed.Error()+": status_createAccount", value)
+
+	// as well as used without a keystore
+	jail = New(nil)
+	defer jail.Stop()
+
+	jail.EnableAccountCreation(true)
+	jail.Parse("cell1", `var _status_catalog = {};`)
+	value = jail.Execute("cell1", `jeth.send({jsonrpc: "2.0", id: 7, method: "status_createAccount", params: ["password"]}).error.message`)
+	require.Equal(t, ErrNoAccountKeyStore.Error(), value)
+}
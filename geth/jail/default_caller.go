@@ -0,0 +1,69 @@
+package jail
+
+import (
+	"encoding/json"
+)
+
+// SetDefaultCaller sets an address which is used as the "from" field
+// of eth_call and eth_estimateGas requests that don't specify it.
+// An empty address disables it.
+func (j *Jail) SetDefaultCaller(address string) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.defaultCaller = address
+}
+
+// injectDefaultCaller sets the default caller in eth_call
+// and eth_estimateGas requests without the "from" field.
+func (j *Jail) injectDefaultCaller(request string) (string, error) {
+	j.settingsMx.RLock()
+	caller := j.defaultCaller
+	j.settingsMx.RUnlock()
+
+	if caller == "" {
+		return request, nil
+	}
+
+	// Malformed requests are handled by the RPC client.
+	if _, err := decodeRequests(request); err != nil {
+		return request, nil
+	}
+
+	from, err := json.Marshal(caller)
+	if err != nil {
+		return "", err
+	}
+
+	return rewriteRequests(request, func(msg map[string]json.RawMessage) error {
+		switch requestMethod(msg) {
+		case "eth_call", "eth_estimateGas":
+		default:
+			return nil
+		}
+
+		var params []json.RawMessage
+		if err := json.Unmarshal(msg["params"], &params); err != nil || len(params) == 0 {
+			return nil
+		}
+
+		var tx map[string]json.RawMessage
+		if err := json.Unmarshal(params[0], &tx); err != nil || tx == nil {
+			return nil
+		}
+
+		if value, ok := tx["from"]; ok && string(value) != "null" {
+			return nil
+		}
+		tx["from"] = from
+
+		data, err := json.Marshal(tx)
+		if err != nil {
+			return err
+		}
+		params[0] = data
+
+		msg["params"], err = json.Marshal(params)
+		return err
+	})
+}
@@ -0,0 +1,42 @@
+package jail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultCaller(t *testing.T) {
+	fromc := make(chan interface{}, 1)
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		var tx map[string]interface{}
+		require.NoError(t, json.Unmarshal(params[0], &tx))
+		fromc <- tx["from"]
+		return "0x", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	defaultCaller := "0xadaf150b905cf5e6a778e553e15a139b6618bbb7"
+	jail.SetDefaultCaller(defaultCaller)
+
+	// from is injected
+	_, err = jail.sendRPCCall(nil, `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x65c01586aa0ce152c2701b2ad1162b8d859a7234"},"latest"]}`)
+	require.NoError(t, err)
+	require.Equal(t, defaultCaller, <-fromc)
+
+	// from is not overridden
+	from := "0x65c01586aa0ce152c2701b2ad1162b8d859a7234"
+	_, err = jail.sendRPCCall(nil, `[{"jsonrpc":"2.0","id":1,"method":"eth_estimateGas","params":[{"from":"`+from+`"}]}]`)
+	require.NoError(t, err)
+	require.Equal(t, from, <-fromc)
+
+	// other methods are not modified
+	_, err = jail.sendRPCCall(nil, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[{}]}`)
+	require.NoError(t, err)
+	require.Nil(t, <-fromc)
+}
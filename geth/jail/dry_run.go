@@ -0,0 +1,137 @@
+package jail
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/status-im/status-go/geth/common"
+)
+
+// SetDryRun enables or disables the dry-run mode, e.g. for DApp test
+// harnesses. In the dry-run mode, RPC requests of cells are recorded
+// instead of being sent, and they get successful responses with results
+// set with SetDryRunResult or null. Subscriptions are recorded as well
+// and get the canned result as their ID, but no notifications. Methods
+// handled by the jail itself, like status_createAccount, are not recorded.
+// See RecordedCalls.
+func (j *Jail) SetDryRun(enabled bool) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.dryRun = enabled
+}
+
+// SetDryRunResult sets a result of requests of a given method
+// in the dry-run mode. The result is marshalled to JSON.
+func (j *Jail) SetDryRunResult(method string, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	if j.dryRunResults == nil {
+		j.dryRunResults = make(map[string]json.RawMessage)
+	}
+	j.dryRunResults[method] = data
+
+	return nil
+}
+
+// RecordedCalls returns RPC requests recorded in the dry-run mode
+// for a cell with chatID, in the order they were sent.
+// Nil is returned if the cell does not exist.
+func (j *Jail) RecordedCalls(chatID string) []common.RPCCall {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return nil
+	}
+
+	cell.recordedMx.Lock()
+	defer cell.recordedMx.Unlock()
+
+	return append([]common.RPCCall(nil), cell.recorded...)
+}
+
+// dryRunResponse records a raw JSON-RPC payload and returns canned
+// responses if the dry-run mode is enabled. It returns false otherwise.
+// Notifications, which have no ID, are recorded but get no response,
+// so the response is nil if the payload has only notifications.
+func (j *Jail) dryRunResponse(cell *Cell, request string) (interface{}, bool) {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	if !j.dryRun {
+		return nil, false
+	}
+
+	requests, err := decodeRequests(request)
+	if err != nil {
+		return nil, false
+	}
+
+	responses := make([]jsonrpcResultResponse, 0, len(requests))
+	for _, req := range requests {
+		if cell != nil {
+			cell.record(newRPCCall(req))
+		}
+
+		// Notifications get no response.
+		if len(req.ID) == 0 {
+			continue
+		}
+
+		result, ok := j.dryRunResults[req.Method]
+		if !ok {
+			result = json.RawMessage(`null`)
+		}
+		responses = append(responses, jsonrpcResultResponse{Version: "2.0", ID: req.ID, Result: result})
+	}
+
+	if len(responses) == 0 {
+		return nil, true
+	}
+
+	var data []byte
+	if isBatchRequest(request) {
+		data, err = json.Marshal(responses)
+	} else {
+		data, err = json.Marshal(responses[0])
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	var response interface{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+
+	return response, true
+}
+
+// newRPCCall converts a decoded request into common.RPCCall.
+// IDs which aren't integers are recorded as zero.
+func newRPCCall(req rpcRequest) common.RPCCall {
+	call := common.RPCCall{
+		Method: req.Method,
+		Params: make([]interface{}, len(req.Params)),
+	}
+	call.ID, _ = strconv.ParseInt(string(req.ID), 10, 64) //nolint: errcheck
+
+	for i, param := range req.Params {
+		json.Unmarshal(param, &call.Params[i]) //nolint: errcheck
+	}
+
+	return call
+}
+
+// record adds a request sent in the dry-run mode to the recorded ones.
+func (c *Cell) record(call common.RPCCall) {
+	c.recordedMx.Lock()
+	defer c.recordedMx.Unlock()
+
+	c.recorded = append(c.recorded, call)
+}
@@ -0,0 +1,62 @@
+package jail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	// no RPC client is needed in the dry-run mode
+	jail := New(nil)
+	defer jail.Stop()
+
+	jail.SetDryRun(true)
+	require.NoError(t, jail.SetDryRunResult("eth_getBalance", "0x64"))
+
+	jail.Parse("cell1", `var _status_catalog = {};`)
+
+	value := jail.Execute("cell1", `JSON.stringify(jeth.send({jsonrpc: "2.0", id: 1, method: "eth_getBalance", params: ["0xb60e8dd61c5d32be8058bb8eb970870f07233155", "latest"]}))`)
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"0x64"}`, value)
+
+	// methods without canned results get null
+	value = jail.Execute("cell1", `var responses = jeth.send([{jsonrpc: "2.0", id: 2, method: "eth_blockNumber", params: []}, {jsonrpc: "2.0", id: "x", method: "eth_getBalance", params: []}]);
+		[responses[0].id, responses[0].result == null, responses[1].id, responses[1].result].join()`)
+	require.Equal(t, `2,true,x,0x64`, value)
+
+	require.Equal(t, []common.RPCCall{
+		{ID: 1, Method: "eth_getBalance", Params: []interface{}{"0xb60e8dd61c5d32be8058bb8eb970870f07233155", "latest"}},
+		{ID: 2, Method: "eth_blockNumber", Params: []interface{}{}},
+		{ID: 0, Method: "eth_getBalance", Params: []interface{}{}},
+	}, jail.RecordedCalls("cell1"))
+	require.Nil(t, jail.RecordedCalls("cell2"))
+
+	// notifications are recorded, but get no response
+	value = jail.Execute("cell1", `var responses = jeth.send([{jsonrpc: "2.0", method: "eth_blockNumber", params: []}, {jsonrpc: "2.0", id: 4, method: "eth_blockNumber", params: []}]);
+		[responses.length, responses[0].id, jeth.send({jsonrpc: "2.0", method: "eth_blockNumber", params: []})].join()`)
+	require.Equal(t, `1,4,`, value)
+	require.Len(t, jail.RecordedCalls("cell1"), 6)
+
+	// subscriptions are not created
+	require.NoError(t, jail.SetDryRunResult("eth_subscribe", "0x1"))
+	jail.Execute("cell1", `var subscription = null;
+		jeth.sendAsync({jsonrpc: "2.0", id: 5, method: "eth_subscribe", params: ["newHeads"]}, function(err, result) {
+			subscription = result.result;
+		});`)
+	deadline := time.Now().Add(5 * time.Second)
+	for jail.Execute("cell1", `subscription`) != "0x1" {
+		require.True(t, time.Now().Before(deadline), "subscription response was not received")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	recorded := jail.RecordedCalls("cell1")
+	require.Equal(t, common.RPCCall{ID: 5, Method: "eth_subscribe", Params: []interface{}{"newHeads"}}, recorded[len(recorded)-1])
+
+	// requests are sent again once the mode is disabled
+	jail.SetDryRun(false)
+	value = jail.Execute("cell1", `jeth.send({jsonrpc: "2.0", id: 6, method: "eth_blockNumber", params: []})`)
+	require.Contains(t, value, ErrNoRPCClient.Error())
+	require.Len(t, jail.RecordedCalls("cell1"), 7)
+}
@@ -0,0 +1,327 @@
+package jail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// DefaultBlockNumberTTL is a default time for which
+// the block number returned by BlockNumber is cached.
+const DefaultBlockNumberTTL = time.Second
+
+// blockNumberCache caches the latest block number.
+type blockNumberCache struct {
+	mx        sync.Mutex
+	ttl       time.Duration
+	client    *rpc.Client // client the number was obtained with
+	number    uint64
+	fetchedAt time.Time
+}
+
+// Balances returns balances of the given addresses at the latest block.
+// All balances are requested in a single batch, see
+// rpc.Client.BatchCallContext for cases when the batch is split.
+func (j *Jail) Balances(ctx context.Context, addresses []string) (map[string]*big.Int, error) {
+	client := j.RPCClient()
+	if client == nil {
+		return nil, ErrNoRPCClient
+	}
+
+	batch := make([]gethrpc.BatchElem, len(addresses))
+	results := make([]hexutil.Big, len(addresses))
+	for i, address := range addresses {
+		if !gethcommon.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address: %s", address)
+		}
+
+		batch[i] = gethrpc.BatchElem{
+			Method: "eth_getBalance",
+			Args:   []interface{}{gethcommon.HexToAddress(address), "latest"},
+			Result: &results[i],
+		}
+	}
+
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]*big.Int, len(addresses))
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("failed to get balance of %s: %v", addresses[i], elem.Error)
+		}
+
+		balances[addresses[i]] = results[i].ToInt()
+	}
+
+	return balances, nil
+}
+
+// SimResult is a result of a simulated transaction.
+type SimResult struct {
+	// Success is false if the transaction reverts.
+	Success bool `json:"success"`
+	// Gas is the estimated gas of a successful transaction.
+	Gas uint64 `json:"gas,omitempty"`
+	// Error is an error returned by the node for a failed transaction.
+	Error string `json:"error,omitempty"`
+}
+
+// SimulateTransaction executes a transaction given in the eth_sendTransaction
+// format as eth_call against the latest block and estimates its gas.
+// The transaction is not broadcasted. Errors returned by the node,
+// like reverts, are reported in SimResult; other errors are returned.
+func (j *Jail) SimulateTransaction(ctx context.Context, tx string) (SimResult, error) {
+	client := j.RPCClient()
+	if client == nil {
+		return SimResult{}, ErrNoRPCClient
+	}
+
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(tx), &args); err != nil {
+		return SimResult{}, fmt.Errorf("invalid transaction: %v", err)
+	}
+	// A nonce is meaningless for a call.
+	delete(args, "nonce")
+
+	var result hexutil.Bytes
+	if err := client.CallContext(ctx, &result, "eth_call", args, "latest"); err != nil {
+		return simulationFailure(err)
+	}
+
+	var gas hexutil.Uint64
+	if err := client.CallContext(ctx, &gas, "eth_estimateGas", args); err != nil {
+		return simulationFailure(err)
+	}
+
+	return SimResult{Success: true, Gas: uint64(gas)}, nil
+}
+
+// simulationFailure returns a failed SimResult if err is returned by the node.
+func simulationFailure(err error) (SimResult, error) {
+	if _, ok := err.(gethrpc.Error); ok {
+		return SimResult{Error: err.Error()}, nil
+	}
+
+	return SimResult{}, err
+}
+
+// SuggestGasPrice returns the gas price suggested by the node.
+func (j *Jail) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	client := j.RPCClient()
+	if client == nil {
+		return nil, ErrNoRPCClient
+	}
+
+	var gasPrice hexutil.Big
+	if err := client.CallContext(ctx, &gasPrice, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+
+	return gasPrice.ToInt(), nil
+}
+
+// SuggestFees returns EIP-1559 fees suggested by the node. The priority fee
+// comes from eth_maxPriorityFeePerGas. The max fee is the priority fee plus
+// twice the base fee of the next block obtained with eth_feeHistory,
+// which covers a few blocks of full base fee growth.
+func (j *Jail) SuggestFees(ctx context.Context) (maxFee, maxPriority *big.Int, err error) {
+	client := j.RPCClient()
+	if client == nil {
+		return nil, nil, ErrNoRPCClient
+	}
+
+	var priorityFee hexutil.Big
+	if err := client.CallContext(ctx, &priorityFee, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, nil, err
+	}
+
+	var feeHistory struct {
+		BaseFeePerGas []hexutil.Big `json:"baseFeePerGas"`
+	}
+	if err := client.CallContext(ctx, &feeHistory, "eth_feeHistory", hexutil.Uint(1), "latest", []float64{}); err != nil {
+		return nil, nil, err
+	}
+	if len(feeHistory.BaseFeePerGas) == 0 {
+		return nil, nil, fmt.Errorf("fee history has no base fee")
+	}
+
+	// The last base fee is the one of the next block.
+	baseFee := feeHistory.BaseFeePerGas[len(feeHistory.BaseFeePerGas)-1].ToInt()
+	maxPriority = priorityFee.ToInt()
+	maxFee = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), maxPriority)
+
+	return maxFee, maxPriority, nil
+}
+
+// SetBlockNumberTTL sets a time for which the block number returned
+// by BlockNumber is cached. Zero disables caching.
+func (j *Jail) SetBlockNumberTTL(ttl time.Duration) {
+	j.blockNumber.mx.Lock()
+	defer j.blockNumber.mx.Unlock()
+
+	j.blockNumber.ttl = ttl
+}
+
+// BlockNumber returns the latest block number. The number is cached
+// for a short time to absorb rapid polling. See SetBlockNumberTTL.
+func (j *Jail) BlockNumber(ctx context.Context) (uint64, error) {
+	client := j.RPCClient()
+	if client == nil {
+		return 0, ErrNoRPCClient
+	}
+
+	cache := &j.blockNumber
+	now := j.now()
+
+	// The lock isn't held while the number is requested, so that a slow
+	// node doesn't block other callers, e.g. the ones with a shorter deadline.
+	cache.mx.Lock()
+	if cache.client == client && now.Sub(cache.fetchedAt) < cache.ttl {
+		number := cache.number
+		cache.mx.Unlock()
+		return number, nil
+	}
+	cache.mx.Unlock()
+
+	var number hexutil.Uint64
+	if err := client.CallContext(ctx, &number, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	// a concurrent call may have stored a more recent number
+	if cache.client != client || !now.Before(cache.fetchedAt) {
+		cache.client = client
+		cache.number = uint64(number)
+		cache.fetchedAt = now
+	}
+
+	return uint64(number), nil
+}
+
+// StorageProof is a Merkle proof of a storage slot value.
+type StorageProof struct {
+	Key   string
+	Value *big.Int
+	Proof []string
+}
+
+// AccountProof is a Merkle proof of an account and its storage slots
+// as returned by eth_getProof.
+type AccountProof struct {
+	Address      gethcommon.Address
+	AccountProof []string
+	Balance      *big.Int
+	CodeHash     gethcommon.Hash
+	Nonce        uint64
+	StorageHash  gethcommon.Hash
+	StorageProof []StorageProof
+}
+
+// accountProofResult is a result of eth_getProof.
+type accountProofResult struct {
+	Address      gethcommon.Address `json:"address"`
+	AccountProof []string           `json:"accountProof"`
+	Balance      *hexutil.Big       `json:"balance"`
+	CodeHash     gethcommon.Hash    `json:"codeHash"`
+	Nonce        hexutil.Uint64     `json:"nonce"`
+	StorageHash  gethcommon.Hash    `json:"storageHash"`
+	StorageProof []struct {
+		Key   string       `json:"key"`
+		Value *hexutil.Big `json:"value"`
+		Proof []string     `json:"proof"`
+	} `json:"storageProof"`
+}
+
+// StorageAt returns the value of a storage slot of a contract at the given
+// block, which is either a hex-encoded number or a tag, like "latest".
+// An empty block means "latest".
+func (j *Jail) StorageAt(ctx context.Context, address, slot, block string) ([]byte, error) {
+	if !gethcommon.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+
+	client := j.RPCClient()
+	if client == nil {
+		return nil, ErrNoRPCClient
+	}
+
+	var value hexutil.Bytes
+	err := client.CallContext(ctx, &value, "eth_getStorageAt",
+		gethcommon.HexToAddress(address), slot, blockOrLatest(block))
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Proof returns a Merkle proof of an account and the given storage slots
+// at the given block. An empty block means "latest".
+func (j *Jail) Proof(ctx context.Context, address string, slots []string, block string) (AccountProof, error) {
+	if !gethcommon.IsHexAddress(address) {
+		return AccountProof{}, fmt.Errorf("invalid address: %s", address)
+	}
+
+	client := j.RPCClient()
+	if client == nil {
+		return AccountProof{}, ErrNoRPCClient
+	}
+
+	if slots == nil {
+		slots = []string{}
+	}
+
+	var result accountProofResult
+	err := client.CallContext(ctx, &result, "eth_getProof",
+		gethcommon.HexToAddress(address), slots, blockOrLatest(block))
+	if err != nil {
+		return AccountProof{}, err
+	}
+
+	proof := AccountProof{
+		Address:      result.Address,
+		AccountProof: result.AccountProof,
+		Balance:      new(big.Int),
+		CodeHash:     result.CodeHash,
+		Nonce:        uint64(result.Nonce),
+		StorageHash:  result.StorageHash,
+		StorageProof: make([]StorageProof, len(result.StorageProof)),
+	}
+	if result.Balance != nil {
+		proof.Balance = result.Balance.ToInt()
+	}
+	for i, storage := range result.StorageProof {
+		proof.StorageProof[i] = StorageProof{
+			Key:   storage.Key,
+			Value: new(big.Int),
+			Proof: storage.Proof,
+		}
+		if storage.Value != nil {
+			proof.StorageProof[i].Value = storage.Value.ToInt()
+		}
+	}
+
+	return proof, nil
+}
+
+// blockOrLatest returns "latest" if block is empty.
+func blockOrLatest(block string) string {
+	if block == "" {
+		return "latest"
+	}
+
+	return block
+}
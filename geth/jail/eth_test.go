@@ -0,0 +1,288 @@
+package jail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalances(t *testing.T) {
+	balances := map[string]string{
+		"0xadaf150b905cf5e6a778e553e15a139b6618bbb7": "0x1",
+		"0x65c01586aa0ce152c2701b2ad1162b8d859a7234": "0xde0b6b3a7640000",
+	}
+
+	var requests int32
+	handler := newTestRPCHTTPHandler(func(method string, params []json.RawMessage) (interface{}, error) {
+		var address string
+		require.NoError(t, json.Unmarshal(params[0], &address))
+		return balances[strings.ToLower(address)], nil
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		handler(w, r)
+	}))
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+	jail := New(provider)
+
+	addresses := []string{
+		"0xAdAf150b905Cf5E6A778E553E15A139B6618BbB7",
+		"0x65C01586aA0Ce152c2701B2aD1162B8D859a7234",
+	}
+	result, err := jail.Balances(context.Background(), addresses)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	require.Equal(t, map[string]*big.Int{
+		addresses[0]: big.NewInt(1),
+		addresses[1]: big.NewInt(1000000000000000000),
+	}, result)
+
+	// invalid address
+	_, err = jail.Balances(context.Background(), []string{"0x123"})
+	require.EqualError(t, err, "invalid address: 0x123")
+
+	// no RPC client
+	_, err = New(nil).Balances(context.Background(), addresses)
+	require.Equal(t, ErrNoRPCClient, err)
+}
+
+func TestSimulateTransaction(t *testing.T) {
+	var methods []string
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		methods = append(methods, method)
+
+		var tx map[string]interface{}
+		require.NoError(t, json.Unmarshal(params[0], &tx))
+		require.NotContains(t, tx, "nonce")
+
+		switch method {
+		case "eth_call":
+			if tx["data"] == "0xdead" {
+				return nil, errors.New("execution reverted")
+			}
+			return "0x", nil
+		case "eth_estimateGas":
+			return "0x5208", nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	// reverting transaction
+	result, err := jail.SimulateTransaction(context.Background(),
+		`{"from":"0xadaf150b905cf5e6a778e553e15a139b6618bbb7","to":"0x65c01586aa0ce152c2701b2ad1162b8d859a7234","data":"0xdead","nonce":"0x1"}`)
+	require.NoError(t, err)
+	require.Equal(t, SimResult{Error: "execution reverted"}, result)
+	require.Equal(t, []string{"eth_call"}, methods)
+
+	// passing transaction
+	result, err = jail.SimulateTransaction(context.Background(),
+		`{"from":"0xadaf150b905cf5e6a778e553e15a139b6618bbb7","to":"0x65c01586aa0ce152c2701b2ad1162b8d859a7234","value":"0x1"}`)
+	require.NoError(t, err)
+	require.Equal(t, SimResult{Success: true, Gas: 21000}, result)
+	require.Equal(t, []string{"eth_call", "eth_call", "eth_estimateGas"}, methods)
+
+	// malformed transaction
+	_, err = jail.SimulateTransaction(context.Background(), `{`)
+	require.Error(t, err)
+
+	// no RPC client
+	_, err = New(nil).SimulateTransaction(context.Background(), `{}`)
+	require.Equal(t, ErrNoRPCClient, err)
+}
+
+func TestSuggestFees(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_gasPrice":
+			return "0x4a817c800", nil
+		case "eth_maxPriorityFeePerGas":
+			return "0x3b9aca00", nil
+		case "eth_feeHistory":
+			require.Equal(t, `"0x1"`, string(params[0]))
+			return map[string]interface{}{
+				"oldestBlock":   "0x10",
+				"baseFeePerGas": []string{"0x2540be400", "0x12a05f200"},
+			}, nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	gasPrice, err := jail.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(20000000000), gasPrice)
+
+	maxFee, maxPriority, err := jail.SuggestFees(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1000000000), maxPriority)
+	require.Equal(t, big.NewInt(2*5000000000+1000000000), maxFee)
+
+	_, err = New(nil).SuggestGasPrice(context.Background())
+	require.Equal(t, ErrNoRPCClient, err)
+}
+
+func TestBlockNumber(t *testing.T) {
+	var requests int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&requests, 1)
+		return "0x1b4", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	now := time.Now()
+	jail := New(provider)
+	jail.now = func() time.Time { return now }
+	jail.SetBlockNumberTTL(time.Second)
+
+	for i := 0; i < 3; i++ {
+		number, err := jail.BlockNumber(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(436), number)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// the cache expires
+	now = now.Add(time.Second)
+	_, err = jail.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// caching can be disabled
+	jail.SetBlockNumberTTL(0)
+	_, err = jail.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestBlockNumberDoesNotBlockCallers(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		requested <- struct{}{}
+		<-unblock
+		return "0x1b4", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := jail.BlockNumber(context.Background())
+		done <- err
+	}()
+	<-requested
+
+	// a caller with a deadline isn't held up by the pending request
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = jail.BlockNumber(ctx)
+	require.Error(t, err)
+
+	jail.SetBlockNumberTTL(time.Minute)
+
+	close(unblock)
+	require.NoError(t, <-done)
+}
+
+func TestStorageAtAndProof(t *testing.T) {
+	const (
+		address = "0xadaf150b905cf5e6a778e553e15a139b6618bbb7"
+		slot    = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	)
+
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		var (
+			requestedAddress string
+			block            string
+		)
+		require.NoError(t, json.Unmarshal(params[0], &requestedAddress))
+		require.Equal(t, address, strings.ToLower(requestedAddress))
+
+		switch method {
+		case "eth_getStorageAt":
+			require.NoError(t, json.Unmarshal(params[2], &block))
+			require.Equal(t, "latest", block)
+			return "0x000000000000000000000000000000000000000000000000000000000000002a", nil
+		case "eth_getProof":
+			var slots []string
+			require.NoError(t, json.Unmarshal(params[1], &slots))
+			require.Equal(t, []string{slot}, slots)
+			require.NoError(t, json.Unmarshal(params[2], &block))
+			require.Equal(t, "0x10", block)
+
+			return map[string]interface{}{
+				"address":      address,
+				"accountProof": []string{"0xf90211", "0xf8518080"},
+				"balance":      "0xde0b6b3a7640000",
+				"codeHash":     "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+				"nonce":        "0x5",
+				"storageHash":  "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+				"storageProof": []interface{}{
+					map[string]interface{}{
+						"key":   slot,
+						"value": "0x2a",
+						"proof": []string{"0xe216a0"},
+					},
+				},
+			}, nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+	jail := New(provider)
+
+	value, err := jail.StorageAt(context.Background(), address, slot, "")
+	require.NoError(t, err)
+	require.Len(t, value, 32)
+	require.Equal(t, byte(42), value[31])
+
+	proof, err := jail.Proof(context.Background(), address, []string{slot}, "0x10")
+	require.NoError(t, err)
+	require.Equal(t, address, strings.ToLower(proof.Address.Hex()))
+	require.Equal(t, []string{"0xf90211", "0xf8518080"}, proof.AccountProof)
+	require.Equal(t, big.NewInt(1000000000000000000), proof.Balance)
+	require.Equal(t, uint64(5), proof.Nonce)
+	require.Equal(t, "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421", proof.StorageHash.Hex())
+	require.Equal(t, []StorageProof{
+		{Key: slot, Value: big.NewInt(42), Proof: []string{"0xe216a0"}},
+	}, proof.StorageProof)
+
+	_, err = jail.StorageAt(context.Background(), "0x123", slot, "")
+	require.EqualError(t, err, "invalid address: 0x123")
+
+	_, err = New(nil).Proof(context.Background(), address, nil, "")
+	require.Equal(t, ErrNoRPCClient, err)
+}
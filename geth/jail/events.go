@@ -0,0 +1,61 @@
+package jail
+
+import (
+	"github.com/robertkrimen/otto"
+)
+
+// CallCollecting works like Call, but additionally collects events emitted
+// with statusEmit(event) while the command is executed. Objects are
+// collected as JSON. The result is returned as JSON without wrapping
+// it into {"result": ...}; errors are returned separately.
+func (j *Jail) CallCollecting(chatID, commandPath, args string) (result string, events []string, err error) {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	events = make([]string, 0)
+	value, err := j.callCell(cell, commandPath, args, callOptions{events: &events})
+	if err != nil {
+		return "", events, err
+	}
+
+	return value.String(), events, nil
+}
+
+// setEventCollector sets a slice collecting emitted events. Nil disables collecting.
+func (c *Cell) setEventCollector(events *[]string) {
+	c.eventsMx.Lock()
+	defer c.eventsMx.Unlock()
+
+	c.events = events
+}
+
+// emit adds an event to the events collector. If no call collects events,
+// the event is dropped.
+func (c *Cell) emit(event string) {
+	c.eventsMx.Lock()
+	defer c.eventsMx.Unlock()
+
+	if c.events != nil {
+		*c.events = append(*c.events, event)
+	}
+}
+
+// registerStatusEmit creates a function called "statusEmit",
+// which emits events collected by CallCollecting.
+func registerStatusEmit(cell *Cell) error {
+	return cell.Set("statusEmit", func(call otto.FunctionCall) otto.Value {
+		event := call.Argument(0)
+		if event.IsObject() {
+			var err error
+			if event, err = call.Otto.Call("JSON.stringify", nil, event); err != nil {
+				throwJSError(err)
+			}
+		}
+
+		cell.emit(event.String())
+
+		return otto.UndefinedValue()
+	})
+}
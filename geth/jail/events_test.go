@@ -0,0 +1,35 @@
+package jail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallCollecting(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			statusEmit("started");
+			statusEmit({type: "progress", value: 50});
+			return "done";
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	result, events, err := jail.CallCollecting("cell1", `["commands", "send"]`, `{}`)
+	require.NoError(t, err)
+	require.Equal(t, "done", result)
+	require.Equal(t, []string{"started", `{"type":"progress","value":50}`}, events)
+
+	// events are not collected by other calls
+	jail.Call("cell1", `["commands", "send"]`, `{}`)
+	_, events, err = jail.CallCollecting("cell1", `["commands", "send"]`, `{}`)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	_, _, err = jail.CallCollecting("cell2", `["commands", "send"]`, `{}`)
+	require.EqualError(t, err, "cell 'cell2' not found")
+}
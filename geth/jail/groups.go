@@ -0,0 +1,100 @@
+package jail
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrMethodNotAllowed is returned to cells calling RPC methods they are not allowed to call.
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// CellConfig defines policies applied to cells.
+type CellConfig struct {
+	// FetchAllowlist is a list of domains which can be requested with statusFetch().
+	FetchAllowlist []string
+	// FetchTimeout is a timeout of statusFetch() requests.
+	FetchTimeout time.Duration
+	// BlockedMethods is a list of RPC methods cells are not allowed to call
+	// in addition to those set with SetBlockedMethods, matched the same way.
+	BlockedMethods []string
+}
+
+// cellGroup is a named group of cells sharing the same configuration.
+type cellGroup struct {
+	mx  sync.RWMutex
+	cfg CellConfig
+}
+
+func (g *cellGroup) config() CellConfig {
+	g.mx.RLock()
+	defer g.mx.RUnlock()
+
+	return g.cfg
+}
+
+func (g *cellGroup) setConfig(cfg CellConfig) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	g.cfg = cfg
+}
+
+// CreateGroup creates a group of cells with a given configuration.
+// If the group already exists, its configuration is replaced
+// and the change applies to all its cells immediately.
+func (j *Jail) CreateGroup(name string, cfg CellConfig) {
+	j.groupsMx.Lock()
+	defer j.groupsMx.Unlock()
+
+	if group, ok := j.groups[name]; ok {
+		group.setConfig(cfg)
+		return
+	}
+
+	group := &cellGroup{}
+	group.setConfig(cfg)
+	j.groups[name] = group
+}
+
+func (j *Jail) group(name string) (*cellGroup, error) {
+	j.groupsMx.RLock()
+	defer j.groupsMx.RUnlock()
+
+	group, ok := j.groups[name]
+	if !ok {
+		return nil, fmt.Errorf("group '%s' not found", name)
+	}
+
+	return group, nil
+}
+
+// ParseInGroup works like Parse, but additionally puts the cell
+// into a group, so that the cell uses the group's configuration.
+func (j *Jail) ParseInGroup(chatID, groupName, code string) string {
+	group, err := j.group(groupName)
+	if err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	return j.parse(chatID, defaultParseOptions(code), group)
+}
+
+// cellConfig returns a configuration of a cell. If the cell doesn't belong
+// to any group, a configuration built from the jail's settings is returned.
+func (j *Jail) cellConfig(cell *Cell) CellConfig {
+	if cell != nil {
+		if group := cell.group(); group != nil {
+			return group.config()
+		}
+	}
+
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	return CellConfig{
+		FetchAllowlist: j.fetchAllowlist,
+		FetchTimeout:   j.fetchTimeout,
+	}
+}
@@ -0,0 +1,54 @@
+package jail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCellGroups(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.CreateGroup("restricted", CellConfig{
+		BlockedMethods: []string{"eth_blockNumber"},
+	})
+
+	// unknown group
+	response := jail.ParseInGroup("cell0", "unknown", "")
+	require.Equal(t, `{"error":"group 'unknown' not found"}`, response)
+
+	jail.ParseInGroup("cell1", "restricted", "")
+	jail.ParseInGroup("cell2", "restricted", "")
+	jail.Parse("cell3", "")
+
+	const blockNumberJS = `web3.eth.blockNumber`
+
+	for _, chatID := range []string{"cell1", "cell2"} {
+		cell, err := jail.Cell(chatID)
+		require.NoError(t, err)
+		_, err = cell.Run(blockNumberJS)
+		require.EqualError(t, err, "Error: "+ErrMethodNotAllowed.Error()+": eth_blockNumber")
+	}
+
+	cell3, err := jail.Cell("cell3")
+	require.NoError(t, err)
+	_, err = cell3.Run(blockNumberJS)
+	require.NoError(t, err)
+
+	// changing group's config applies to all cells of the group
+	jail.CreateGroup("restricted", CellConfig{})
+	for _, chatID := range []string{"cell1", "cell2"} {
+		cell, err := jail.Cell(chatID)
+		require.NoError(t, err)
+		_, err = cell.Run(blockNumberJS)
+		require.NoError(t, err)
+	}
+}
@@ -1,10 +1,13 @@
 package jail
 
 import (
+	"fmt"
 	"os"
+	"sync/atomic"
 
 	"github.com/robertkrimen/otto"
 	"github.com/status-im/status-go/geth/jail/console"
+	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/signal"
 )
 
@@ -32,6 +35,43 @@ func registerWeb3Provider(jail *Jail, cell *Cell) error {
 	return cell.Set("jeth", jeth)
 }
 
+// consoleLoggers maps methods of the console object to log levels.
+var consoleLoggers = map[string]func(msg string, ctx ...interface{}){
+	"log":   log.Info,
+	"warn":  log.Warn,
+	"error": log.Error,
+}
+
+// registerConsole replaces the console object of the VM, which writes
+// to stdout, with one forwarding messages to the logger, prefixed
+// with the chat ID. It's called before any JS code runs,
+// so scripts can still redefine console.
+func registerConsole(cell *Cell) error {
+	methods := make(map[string]interface{}, len(consoleLoggers))
+	for name, logFn := range consoleLoggers {
+		logFn := logFn
+		methods[name] = func(call otto.FunctionCall) otto.Value {
+			logFn(fmt.Sprintf("[%s] %s", cell.id, console.Format(call.ArgumentList)))
+			return otto.UndefinedValue()
+		}
+	}
+
+	return cell.Set("console", methods)
+}
+
+// registerSilentConsole replaces the console of a cell with one
+// discarding messages.
+func registerSilentConsole(cell *Cell) error {
+	methods := make(map[string]interface{}, len(consoleLoggers))
+	for name := range consoleLoggers {
+		methods[name] = func(call otto.FunctionCall) otto.Value {
+			return otto.UndefinedValue()
+		}
+	}
+
+	return cell.Set("console", methods)
+}
+
 // registerStatusSignals creates an object called "statusSignals".
 // TODO(adam): describe what it is and when it's used.
 func registerStatusSignals(cell *Cell) error {
@@ -42,6 +82,12 @@ func registerStatusSignals(cell *Cell) error {
 	return cell.Set("statusSignals", statusSignals)
 }
 
+// registerStatusFetch creates a function called "statusFetch",
+// which performs HTTP requests to allowlisted domains.
+func registerStatusFetch(jail *Jail, cell *Cell) error {
+	return cell.Set("statusFetch", createStatusFetchHandler(jail, cell))
+}
+
 // createSendHandler returns jeth.send().
 func createSendHandler(jail *Jail, cell *Cell) func(call otto.FunctionCall) otto.Value {
 	return func(call otto.FunctionCall) otto.Value {
@@ -55,7 +101,10 @@ func createSendHandler(jail *Jail, cell *Cell) func(call otto.FunctionCall) otto
 			throwJSError(err)
 		}
 
-		response, err := jail.sendRPCCall(request.String())
+		response, err := jail.sendRPCCall(cell, request.String())
+		if isTransportFailure(response, err) {
+			atomic.StoreInt32(&cell.rpcFailed, 1)
+		}
 		if err != nil {
 			throwJSError(err)
 		}
@@ -82,20 +131,46 @@ func createSendAsyncHandler(jail *Jail, cell *Cell) func(call otto.FunctionCall)
 			throwJSError(err)
 		}
 
+		// The priority of the call in progress can't be checked later.
+		high := cell.highPriority()
+
 		go func() {
 			// As it's an async call, it's not called from a thread-safe context,
 			// thus using a thread-safe vm.VM.
 			vm := cell.VM
 			callback := call.Argument(1)
-			response, err := jail.sendRPCCall(request.String())
+
+			var (
+				response interface{}
+				err      error
+				forward  func()
+			)
+			if isSubscribeRequest(request.String()) && callback.Class() == "Function" {
+				response, forward, err = jail.subscribe(cell, request.String(), callback, high)
+			} else {
+				response, err = jail.sendRPCCallWithPriority(cell, request.String(), high)
+			}
+
+			// Notifications are forwarded once the callback got the subscription ID.
+			if forward != nil {
+				defer func() { go forward() }()
+			}
 
 			// If provided callback argument is not a function, don't call it.
 			if callback.Class() != "Function" {
 				return
 			}
 
+			// The loop of a stopped cell doesn't run callbacks anymore.
+			if cell.ctx.Err() != nil {
+				return
+			}
+
 			if err != nil {
 				cell.CallAsync(callback, vm.MakeCustomError("Error", err.Error()))
+			} else if rpcErr, ok := responseError(response); ok {
+				// Follow callback(error, result) semantics of web3.js providers.
+				cell.CallAsync(callback, rpcErr, otto.NullValue())
 			} else {
 				cell.CallAsync(callback, nil, response)
 			}
@@ -105,6 +180,22 @@ func createSendAsyncHandler(jail *Jail, cell *Cell) func(call otto.FunctionCall)
 	}
 }
 
+// responseError returns the error object of a single JSON-RPC response.
+// Errors of batch responses are not returned, as they are per request.
+func responseError(response interface{}) (interface{}, bool) {
+	m, ok := response.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	rpcErr, ok := m["error"]
+	if !ok || rpcErr == nil {
+		return nil, false
+	}
+
+	return rpcErr, true
+}
+
 // createIsConnectedHandler returns jeth.isConnected() handler.
 // This handler returns `true` if client is actively listening for network connections.
 func createIsConnectedHandler(jail RPCClientProvider) func(call otto.FunctionCall) otto.Value {
@@ -127,6 +218,51 @@ func createIsConnectedHandler(jail RPCClientProvider) func(call otto.FunctionCal
 	}
 }
 
+// createStatusFetchHandler returns statusFetch() handler.
+// It accepts a URL and optional object with method, headers and body
+// and returns an object with status and body of the response.
+func createStatusFetchHandler(jail *Jail, cell *Cell) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		var opts statusFetchOptions
+		if arg := call.Argument(1); arg.IsObject() {
+			object := arg.Object()
+			if value, err := object.Get("method"); err == nil && value.IsDefined() {
+				opts.Method = value.String()
+			}
+			if value, err := object.Get("body"); err == nil && value.IsDefined() {
+				opts.Body = value.String()
+			}
+			if value, err := object.Get("headers"); err == nil && value.IsObject() {
+				opts.Headers = make(map[string]string)
+				for _, key := range value.Object().Keys() {
+					header, _ := value.Object().Get(key)
+					opts.Headers[key] = header.String()
+				}
+			}
+		}
+
+		response, err := jail.statusFetch(cell, call.Argument(0).String(), opts)
+		if err != nil {
+			throwJSError(err)
+		}
+
+		// As it's a sync call, it's called already from a thread-safe context,
+		// thus using otto.Otto directly. Otherwise, it would try to acquire a lock again
+		// and result in a deadlock.
+		vm := cell.VM.UnsafeVM()
+
+		value, err := vm.ToValue(map[string]interface{}{
+			"status": response.Status,
+			"body":   response.Body,
+		})
+		if err != nil {
+			throwJSError(err)
+		}
+
+		return value
+	}
+}
+
 func createSendSignalHandler(cell *Cell) func(otto.FunctionCall) otto.Value {
 	return func(call otto.FunctionCall) otto.Value {
 		message := call.Argument(0).String()
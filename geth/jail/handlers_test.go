@@ -142,6 +142,38 @@ func (s *HandlersTestSuite) TestWeb3SendAsyncHandlerFailure() {
 	s.Equal(`undefined`, <-resultc)
 }
 
+func (s *HandlersTestSuite) TestWeb3SendAsyncHandlerErrorResponse() {
+	s.responseFixture = `{"jsonrpc":"2.0","id":10,"error":{"code":-32000,"message":"failure"}}`
+
+	client, err := rpc.NewClient(s.client, params.UpstreamRPCConfig{})
+	s.NoError(err)
+
+	jail := New(&testRPCClientProvider{client})
+
+	cell, err := jail.createAndInitCell("cell1")
+	s.NoError(err)
+
+	errc := make(chan otto.Value)
+	resultc := make(chan string)
+	err = cell.Set("__callback", func(call otto.FunctionCall) otto.Value {
+		errc <- call.Argument(0)
+		resultc <- call.Argument(1).String()
+		return otto.UndefinedValue()
+	})
+	s.NoError(err)
+
+	_, err = cell.Run(`jeth.sendAsync({"jsonrpc":"2.0","id":10,"method":"eth_syncing","params":[]}, __callback)`)
+	s.NoError(err)
+
+	errValue := <-errc
+	s.True(errValue.IsObject())
+	message, err := errValue.Object().Get("message")
+	s.NoError(err)
+
+	s.Equal("failure", message.String())
+	s.Equal(`null`, <-resultc)
+}
+
 func (s *HandlersTestSuite) TestWeb3IsConnectedHandler() {
 	client, err := rpc.NewClient(s.client, params.UpstreamRPCConfig{})
 	s.NoError(err)
@@ -185,3 +217,71 @@ func (s *HandlersTestSuite) TestSendSignalHandler() {
 	s.NoError(err)
 	s.True(resultBool)
 }
+
+func (s *HandlersTestSuite) TestStatusFetchHandler() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s", r.Method, r.Header.Get("X-Test"))
+	}))
+	defer ts.Close()
+
+	jail := New(nil)
+	jail.SetFetchAllowlist([]string{"127.0.0.1"})
+
+	cell, err := jail.createAndInitCell("cell1")
+	s.NoError(err)
+
+	value, err := cell.Run(`
+		var response = statusFetch("` + ts.URL + `", {method: "POST", headers: {"X-Test": "ok"}});
+		response.status + ": " + response.body
+	`)
+	s.NoError(err)
+	s.Equal("201: POST ok", value.String())
+
+	// domain is not allowlisted
+	domains := []string{"example.com"}
+	jail.SetFetchAllowlist(domains)
+	_, err = cell.Run(`statusFetch("` + ts.URL + `")`)
+	s.EqualError(err, ErrFetchDomainNotAllowed.Error()+": 127.0.0.1")
+
+	// changing the slice doesn't change the allowlist
+	domains[0] = "127.0.0.1"
+	_, err = cell.Run(`statusFetch("` + ts.URL + `")`)
+	s.EqualError(err, ErrFetchDomainNotAllowed.Error()+": 127.0.0.1")
+}
+
+func (s *HandlersTestSuite) TestConsoleHandler() {
+	var messages []string
+	original := consoleLoggers
+	consoleLoggers = map[string]func(msg string, ctx ...interface{}){}
+	for _, name := range []string{"log", "warn", "error"} {
+		name := name
+		consoleLoggers[name] = func(msg string, ctx ...interface{}) {
+			messages = append(messages, name+" "+msg)
+		}
+	}
+	defer func() { consoleLoggers = original }()
+
+	jail := New(nil)
+
+	cell, err := jail.createAndInitCell("cell1")
+	s.NoError(err)
+
+	_, err = cell.Run(`console.log("balance", 42, {a: [1, "b"]}); console.warn("low"); console.error(null)`)
+	s.NoError(err)
+	s.Equal([]string{
+		`log [cell1] balance 42 {"a":[1,"b"]}`,
+		`warn [cell1] low`,
+		`error [cell1] null`,
+	}, messages)
+
+	// scripts can redefine console
+	jail.SetBaseJS(`var console = {log: function() { return "custom"; }};`)
+	cell, err = jail.createAndInitCell("cell2")
+	s.NoError(err)
+
+	value, err := cell.Run(`console.log("message")`)
+	s.NoError(err)
+	s.Equal("custom", value.String())
+	s.Len(messages, 3)
+}
@@ -0,0 +1,259 @@
+package jail
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+const (
+	// DefaultLogPageSize is a default number of blocks requested with a single eth_getLogs.
+	DefaultLogPageSize = 10000
+
+	// blockScanBatchSize is a number of blocks requested in a single batch
+	// when looking for transactions of an account.
+	blockScanBatchSize = 100
+)
+
+// Transfer types
+const (
+	TransferTypeEth   = "eth"
+	TransferTypeERC20 = "erc20"
+)
+
+// erc20TransferTopic is a topic of Transfer(address,address,uint256) events.
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// Transfer is a transfer of ether or ERC20 tokens.
+type Transfer struct {
+	Type        string             `json:"type"`
+	TxHash      gethcommon.Hash    `json:"txHash"`
+	BlockNumber uint64             `json:"blockNumber"`
+	From        gethcommon.Address `json:"from"`
+	To          gethcommon.Address `json:"to"`
+	Value       *big.Int           `json:"value"`
+	// Contract is an address of the token contract of ERC20 transfers.
+	Contract *gethcommon.Address `json:"contract,omitempty"`
+
+	// Position of the transfer within the block used for sorting.
+	// Ether transfers have logIndex -1, so that they precede
+	// ERC20 transfers logged by the same transaction.
+	txIndex  uint
+	logIndex int
+}
+
+// SetLogPageSize sets a number of blocks requested with a single eth_getLogs,
+// as nodes often limit the range of blocks which can be queried.
+// Zero means no pagination.
+func (j *Jail) SetLogPageSize(blocks uint64) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.logPageSize = blocks
+}
+
+// TransferHistory returns ether and ERC20 transfers sent or received by an address
+// within a range of blocks, sorted by block, transaction index and log index,
+// ether transfers preceding ERC20 transfers of the same transaction. ERC20 transfers are found with
+// eth_getLogs and ether transfers by scanning transactions of each block.
+func (j *Jail) TransferHistory(ctx context.Context, address string, fromBlock, toBlock uint64) ([]Transfer, error) {
+	if !gethcommon.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("invalid block range: %d > %d", fromBlock, toBlock)
+	}
+
+	client := j.RPCClient()
+	if client == nil {
+		return nil, ErrNoRPCClient
+	}
+
+	account := gethcommon.HexToAddress(address)
+
+	transfers, err := j.tokenTransfers(ctx, client, account, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	ethTransfers, err := ethTransfers(ctx, client, account, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	transfers = append(transfers, ethTransfers...)
+
+	sort.SliceStable(transfers, func(i, k int) bool {
+		a, b := transfers[i], transfers[k]
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		if a.txIndex != b.txIndex {
+			return a.txIndex < b.txIndex
+		}
+		return a.logIndex < b.logIndex
+	})
+
+	return transfers, nil
+}
+
+// transferLog is a log returned by eth_getLogs.
+type transferLog struct {
+	Address     gethcommon.Address `json:"address"`
+	Topics      []gethcommon.Hash  `json:"topics"`
+	Data        hexutil.Bytes      `json:"data"`
+	BlockNumber hexutil.Uint64     `json:"blockNumber"`
+	TxHash      gethcommon.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint       `json:"transactionIndex"`
+	LogIndex    hexutil.Uint       `json:"logIndex"`
+}
+
+// tokenTransfers returns ERC20 transfers from and to an account.
+func (j *Jail) tokenTransfers(ctx context.Context, client *rpc.Client, account gethcommon.Address, fromBlock, toBlock uint64) ([]Transfer, error) {
+	j.settingsMx.RLock()
+	pageSize := j.logPageSize
+	j.settingsMx.RUnlock()
+
+	if pageSize == 0 {
+		pageSize = toBlock - fromBlock + 1
+	}
+
+	accountTopic := gethcommon.BytesToHash(account.Bytes())
+	topicFilters := [][]interface{}{
+		{erc20TransferTopic, accountTopic},
+		{erc20TransferTopic, nil, accountTopic},
+	}
+
+	type logID struct {
+		txHash gethcommon.Hash
+		index  uint
+	}
+	seen := make(map[logID]bool)
+
+	var transfers []Transfer
+	for start := fromBlock; start <= toBlock; start += pageSize {
+		end := start + pageSize - 1
+		if end > toBlock || end < start {
+			end = toBlock
+		}
+
+		for _, topics := range topicFilters {
+			filter := map[string]interface{}{
+				"fromBlock": hexutil.Uint64(start),
+				"toBlock":   hexutil.Uint64(end),
+				"topics":    topics,
+			}
+
+			var logs []transferLog
+			if err := client.CallContext(ctx, &logs, "eth_getLogs", filter); err != nil {
+				return nil, err
+			}
+
+			for _, log := range logs {
+				// ERC721 transfers have the same signature but 4 topics.
+				if len(log.Topics) != 3 || log.Topics[0] != erc20TransferTopic {
+					continue
+				}
+
+				id := logID{log.TxHash, uint(log.LogIndex)}
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+
+				contract := log.Address
+				transfers = append(transfers, Transfer{
+					Type:        TransferTypeERC20,
+					TxHash:      log.TxHash,
+					BlockNumber: uint64(log.BlockNumber),
+					From:        gethcommon.BytesToAddress(log.Topics[1].Bytes()),
+					To:          gethcommon.BytesToAddress(log.Topics[2].Bytes()),
+					Value:       new(big.Int).SetBytes(log.Data),
+					Contract:    &contract,
+					txIndex:     uint(log.TxIndex),
+					logIndex:    int(log.LogIndex),
+				})
+			}
+		}
+
+		if end == toBlock {
+			break
+		}
+	}
+
+	return transfers, nil
+}
+
+// blockTransactions is a block returned by eth_getBlockByNumber with transactions.
+type blockTransactions struct {
+	Transactions []struct {
+		Hash             gethcommon.Hash     `json:"hash"`
+		From             gethcommon.Address  `json:"from"`
+		To               *gethcommon.Address `json:"to"`
+		Value            hexutil.Big         `json:"value"`
+		TransactionIndex hexutil.Uint        `json:"transactionIndex"`
+	} `json:"transactions"`
+}
+
+// ethTransfers returns non-zero ether transfers from and to an account.
+func ethTransfers(ctx context.Context, client *rpc.Client, account gethcommon.Address, fromBlock, toBlock uint64) ([]Transfer, error) {
+	var transfers []Transfer
+	for start := fromBlock; start <= toBlock; start += blockScanBatchSize {
+		end := start + blockScanBatchSize - 1
+		if end > toBlock || end < start {
+			end = toBlock
+		}
+
+		blocks := make([]blockTransactions, end-start+1)
+		batch := make([]gethrpc.BatchElem, len(blocks))
+		for i := range batch {
+			batch[i] = gethrpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []interface{}{hexutil.Uint64(start + uint64(i)), true},
+				Result: &blocks[i],
+			}
+		}
+
+		if err := client.BatchCallContext(ctx, batch); err != nil {
+			return nil, err
+		}
+
+		for i, block := range blocks {
+			if batch[i].Error != nil {
+				return nil, fmt.Errorf("failed to get block %d: %v", start+uint64(i), batch[i].Error)
+			}
+
+			for _, tx := range block.Transactions {
+				if tx.To == nil || tx.Value.ToInt().Sign() == 0 {
+					continue
+				}
+				if tx.From != account && *tx.To != account {
+					continue
+				}
+
+				transfers = append(transfers, Transfer{
+					Type:        TransferTypeEth,
+					TxHash:      tx.Hash,
+					BlockNumber: start + uint64(i),
+					From:        tx.From,
+					To:          *tx.To,
+					Value:       tx.Value.ToInt(),
+					txIndex:     uint(tx.TransactionIndex),
+					logIndex:    -1,
+				})
+			}
+		}
+
+		if end == toBlock {
+			break
+		}
+	}
+
+	return transfers, nil
+}
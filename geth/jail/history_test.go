@@ -0,0 +1,137 @@
+package jail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferHistory(t *testing.T) {
+	account := gethcommon.HexToAddress("0xadaf150b905cf5e6a778e553e15a139b6618bbb7")
+	other := gethcommon.HexToAddress("0x65c01586aa0ce152c2701b2ad1162b8d859a7234")
+	token := gethcommon.HexToAddress("0x744d70fdbe2ba4cf95131626614a1763df805b9e")
+	accountTopic := gethcommon.BytesToHash(account.Bytes()).Hex()
+	otherTopic := gethcommon.BytesToHash(other.Bytes()).Hex()
+
+	transferLog := func(block uint64, txIndex, logIndex uint, from, to string, extraTopics ...string) map[string]interface{} {
+		return map[string]interface{}{
+			"address":          token.Hex(),
+			"topics":           append([]string{erc20TransferTopic.Hex(), from, to}, extraTopics...),
+			"data":             gethcommon.BigToHash(big.NewInt(500)).Hex(),
+			"blockNumber":      hexutil.Uint64(block),
+			"transactionHash":  gethcommon.BigToHash(big.NewInt(int64(block))).Hex(),
+			"transactionIndex": hexutil.Uint(txIndex),
+			"logIndex":         hexutil.Uint(logIndex),
+		}
+	}
+
+	var logRequests int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_getLogs":
+			atomic.AddInt32(&logRequests, 1)
+			var filter struct {
+				FromBlock hexutil.Uint64     `json:"fromBlock"`
+				Topics    []*gethcommon.Hash `json:"topics"`
+			}
+			require.NoError(t, json.Unmarshal(params[0], &filter))
+
+			if filter.FromBlock != 5 {
+				return []interface{}{}, nil
+			}
+			if len(filter.Topics) == 2 { // sent by account
+				return []interface{}{transferLog(7, 3, 0, accountTopic, otherTopic)}, nil
+			}
+			return []interface{}{
+				transferLog(6, 0, 0, otherTopic, accountTopic),
+				// logged at a lower transaction index, but a higher log index
+				// than the ether transfer in the same block
+				transferLog(7, 1, 5, otherTopic, accountTopic),
+				// ERC721 transfer is ignored
+				transferLog(8, 0, 0, otherTopic, accountTopic, gethcommon.BigToHash(big.NewInt(1)).Hex()),
+			}, nil
+		case "eth_getBlockByNumber":
+			var number hexutil.Uint64
+			require.NoError(t, json.Unmarshal(params[0], &number))
+
+			txs := []interface{}{}
+			switch number {
+			case 3:
+				txs = append(txs, map[string]interface{}{
+					"hash": gethcommon.BigToHash(big.NewInt(3)).Hex(), "from": account.Hex(), "to": other.Hex(),
+					"value": "0xde0b6b3a7640000", "transactionIndex": "0x0",
+				})
+			case 4:
+				// zero value transactions are ignored
+				txs = append(txs, map[string]interface{}{
+					"hash": gethcommon.BigToHash(big.NewInt(4)).Hex(), "from": account.Hex(), "to": token.Hex(),
+					"value": "0x0", "transactionIndex": "0x0",
+				})
+			case 7:
+				// the transaction also logs the ERC20 transfer at index 3
+				txs = append(txs, map[string]interface{}{
+					"hash": gethcommon.BigToHash(big.NewInt(7)).Hex(), "from": account.Hex(), "to": token.Hex(),
+					"value": "0x1", "transactionIndex": "0x3",
+				})
+			}
+			return map[string]interface{}{"transactions": txs}, nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.SetLogPageSize(5)
+
+	transfers, err := jail.TransferHistory(context.Background(), account.Hex(), 0, 9)
+	require.NoError(t, err)
+	// 2 pages of blocks, each with 2 filters
+	require.Equal(t, int32(4), atomic.LoadInt32(&logRequests))
+
+	require.Len(t, transfers, 5)
+
+	require.Equal(t, TransferTypeEth, transfers[0].Type)
+	require.Equal(t, uint64(3), transfers[0].BlockNumber)
+	require.Equal(t, account, transfers[0].From)
+	require.Equal(t, other, transfers[0].To)
+	require.Equal(t, "1000000000000000000", transfers[0].Value.String())
+	require.Nil(t, transfers[0].Contract)
+
+	require.Equal(t, TransferTypeERC20, transfers[1].Type)
+	require.Equal(t, uint64(6), transfers[1].BlockNumber)
+	require.Equal(t, other, transfers[1].From)
+	require.Equal(t, account, transfers[1].To)
+	require.Equal(t, big.NewInt(500), transfers[1].Value)
+	require.Equal(t, token, *transfers[1].Contract)
+
+	// transfers within a block are sorted by transaction and log index,
+	// ether transfers first
+	for i, expected := range []struct {
+		typ     string
+		txIndex uint
+		from    gethcommon.Address
+	}{
+		{TransferTypeERC20, 1, other},
+		{TransferTypeEth, 3, account},
+		{TransferTypeERC20, 3, account},
+	} {
+		transfer := transfers[2+i]
+		require.Equal(t, uint64(7), transfer.BlockNumber)
+		require.Equal(t, expected.typ, transfer.Type)
+		require.Equal(t, expected.txIndex, transfer.txIndex)
+		require.Equal(t, expected.from, transfer.From)
+	}
+
+	_, err = jail.TransferHistory(context.Background(), "0xinvalid", 0, 9)
+	require.EqualError(t, err, "invalid address: 0xinvalid")
+}
@@ -35,11 +35,13 @@ type Task interface {
 // Otherwise, on ARM and x86-32 it will panic.
 // More information: https://golang.org/pkg/sync/atomic/#pkg-note-BUG.
 type Loop struct {
-	id    int64
-	vm    *vm.VM
-	lock  sync.RWMutex
-	tasks map[int64]Task
-	ready chan Task
+	id       int64
+	vm       *vm.VM
+	lock     sync.RWMutex
+	tasks    map[int64]Task
+	ready    chan Task
+	stopped  chan struct{} // closed when Run returns
+	stopOnce sync.Once
 }
 
 // New creates a new Loop with an unbuffered ready queue on a specific VM.
@@ -51,9 +53,10 @@ func New(vm *vm.VM) *Loop {
 // queue, the capacity of which being specified by the backlog argument.
 func NewWithBacklog(vm *vm.VM, backlog int) *Loop {
 	return &Loop{
-		vm:    vm,
-		tasks: make(map[int64]Task),
-		ready: make(chan Task, backlog),
+		vm:      vm,
+		tasks:   make(map[int64]Task),
+		ready:   make(chan Task, backlog),
+		stopped: make(chan struct{}),
 	}
 }
 
@@ -100,9 +103,18 @@ func (l *Loop) removeAll() {
 }
 
 // Ready signals to the loop that a task is ready to be finalised. This might
-// block if the "ready channel" in the loop is at capacity.
+// block if the "ready channel" in the loop is at capacity. If the loop
+// is stopped, e.g. a timer fires after its cell was removed, the task
+// is cancelled and dropped instead.
 func (l *Loop) Ready(t Task) {
-	l.ready <- t
+	select {
+	case l.ready <- t:
+	case <-l.stopped:
+		if t != nil {
+			l.remove(t)
+			t.Cancel()
+		}
+	}
 }
 
 // Eval executes some code in the VM associated with the loop and returns an
@@ -129,8 +141,10 @@ func (l *Loop) processTask(t Task) error {
 }
 
 // Run handles the task scheduling and finalisation.
-// It runs infinitely waiting for new tasks.
+// It runs infinitely waiting for new tasks until the context is done.
 func (l *Loop) Run(ctx context.Context) error {
+	defer l.stopOnce.Do(func() { close(l.stopped) })
+
 	for {
 		select {
 		case t := <-l.ready:
@@ -0,0 +1,77 @@
+package loop_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/geth/jail/internal/loop"
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+	"github.com/stretchr/testify/require"
+)
+
+type testTask struct {
+	id        int64
+	executed  chan struct{}
+	cancelled chan struct{}
+}
+
+func newTestTask() *testTask {
+	return &testTask{
+		executed:  make(chan struct{}, 1),
+		cancelled: make(chan struct{}, 1),
+	}
+}
+
+func (t *testTask) SetID(id int64) { t.id = id }
+func (t *testTask) GetID() int64   { return t.id }
+
+func (t *testTask) Execute(vm *vm.VM, l *loop.Loop) error {
+	t.executed <- struct{}{}
+	return nil
+}
+
+func (t *testTask) Cancel() {
+	t.cancelled <- struct{}{}
+}
+
+func TestReadyAfterStop(t *testing.T) {
+	l := loop.New(vm.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- l.Run(ctx)
+	}()
+
+	task := newTestTask()
+	l.Add(task)
+	l.Ready(task)
+	select {
+	case <-task.executed:
+	case <-time.After(time.Second):
+		t.Fatal("task was not executed")
+	}
+
+	cancel()
+	require.Equal(t, context.Canceled, <-stopped)
+
+	// A task becoming ready after the loop stopped, e.g. a timer
+	// of a removed cell, is cancelled instead of blocking forever.
+	task = newTestTask()
+	l.Add(task)
+
+	done := make(chan struct{})
+	go func() {
+		l.Ready(task)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Ready blocked after the loop was stopped")
+	}
+	require.Len(t, task.cancelled, 1)
+	require.Len(t, task.executed, 0)
+}
@@ -1,11 +1,17 @@
 package vm
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/robertkrimen/otto"
 )
 
+// ErrTimeout is returned when code run with RunTimeout is interrupted.
+var ErrTimeout = errors.New("execution timed out")
+
 // VM implements concurrency safe wrapper to
 // otto's VM object.
 type VM struct {
@@ -44,17 +50,44 @@ func (vm *VM) Get(key string) (otto.Value, error) {
 
 // Call attempts to call the internal call function for the giving response associated with the
 // proper values.
-func (vm *VM) Call(item string, this interface{}, args ...interface{}) (otto.Value, error) {
+func (vm *VM) Call(item string, this interface{}, args ...interface{}) (value otto.Value, err error) {
 	vm.Lock()
 	defer vm.Unlock()
+	defer recoverPanic(&err)
 
 	return vm.vm.Call(item, this, args...)
 }
 
 // Run evaluates JS source, which may be string or otto.Script variable.
-func (vm *VM) Run(src interface{}) (otto.Value, error) {
+func (vm *VM) Run(src interface{}) (value otto.Value, err error) {
+	vm.Lock()
+	defer vm.Unlock()
+	defer recoverPanic(&err)
+
+	return vm.vm.Run(src)
+}
+
+// RunTimeout works like Run, but the code is interrupted with ErrTimeout
+// if it runs longer than timeout, e.g. an infinite loop. Zero timeout
+// means no limit.
+func (vm *VM) RunTimeout(src interface{}, timeout time.Duration) (value otto.Value, err error) {
+	if timeout <= 0 {
+		return vm.Run(src)
+	}
+
 	vm.Lock()
 	defer vm.Unlock()
+	defer recoverPanic(&err)
+
+	interrupt := make(chan func(), 1)
+	vm.vm.Interrupt = interrupt
+	timer := time.AfterFunc(timeout, func() {
+		interrupt <- func() { panic(ErrTimeout) }
+	})
+	defer func() {
+		timer.Stop()
+		vm.vm.Interrupt = nil
+	}()
 
 	return vm.vm.Run(src)
 }
@@ -90,3 +123,21 @@ func (vm *VM) MakeCustomError(name, message string) otto.Value {
 
 	return vm.vm.MakeCustomError(name, message)
 }
+
+// recoverPanic converts a panic of Go code called from JavaScript,
+// which otto doesn't turn into an exception, into an error.
+func recoverPanic(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	switch v := r.(type) {
+	case otto.Value:
+		*err = errors.New(v.String())
+	case error:
+		*err = v
+	default:
+		*err = fmt.Errorf("%v", v)
+	}
+}
@@ -1,19 +1,30 @@
 package jail
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/robertkrimen/otto"
 	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/rpc"
 	"github.com/status-im/status-go/static"
 )
 
 const (
+	// cellDisabledErrorCode is a JSON-RPC error code
+	// of requests sent by a disabled cell.
+	cellDisabledErrorCode = -32000
+
 	web3InstanceCode = `
 		var Web3 = require('web3');
 		var web3 = new Web3(jeth);
@@ -21,6 +32,12 @@ const (
 		function bn(val) {
 			return new Bignumber(val);
 		}
+		function statusJSONReplacer(key, val) {
+			if (this[key] instanceof Bignumber) {
+				return this[key].toString(10);
+			}
+			return val;
+		}
 	`
 )
 
@@ -28,8 +45,30 @@ var (
 	web3Code = string(static.MustAsset("scripts/web3.js"))
 	// ErrNoRPCClient is returned when an RPC client is required but it's nil.
 	ErrNoRPCClient = errors.New("RPC client is not available")
+	// ErrCellDisabled is returned when a disabled cell is called.
+	ErrCellDisabled = errors.New("cell disabled")
+	// ErrInvalidWeb3JS is returned when a web3.js bundle set with SetWeb3JS doesn't define Web3.
+	ErrInvalidWeb3JS = errors.New("web3.js bundle doesn't define Web3")
+	// ErrNoCatalog is returned when a parsed script doesn't set _status_catalog.
+	ErrNoCatalog = errors.New("_status_catalog is not set")
+	// ErrCellNotFound matches errors returned for unknown cells with errors.Is.
+	ErrCellNotFound = errors.New("cell not found")
 )
 
+// CellNotFoundError is returned when a cell with ChatID does not exist.
+type CellNotFoundError struct {
+	ChatID string
+}
+
+func (e *CellNotFoundError) Error() string {
+	return fmt.Sprintf("cell '%s' not found", e.ChatID)
+}
+
+// Is returns true for ErrCellNotFound.
+func (e *CellNotFoundError) Is(target error) bool {
+	return target == ErrCellNotFound
+}
+
 // RPCClientProvider is an interface that provides a way
 // to obtain an rpc.Client.
 type RPCClientProvider interface {
@@ -46,6 +85,73 @@ type Jail struct {
 	baseJS            string
 	cellsMx           sync.RWMutex
 	cells             map[string]*Cell
+	groupsMx          sync.RWMutex
+	groups            map[string]*cellGroup
+	rpcScheduler      *rpcScheduler
+
+	clientMx sync.Mutex  // guards rpcClientProvider and client
+	client   *rpc.Client // last client obtained from rpcClientProvider
+
+	// upstream RPC provider, guarded by clientMx, see SetUpstreamRPC
+	upstreamURL     string
+	upstreamClient  *rpc.Client   // routes requests of client to upstreamURL
+	upstreamBackoff time.Duration // delay before the next dial after a failure
+	upstreamDialAt  time.Time     // time before which dialing is not retried
+
+	// now returns the current time, it can be replaced in tests.
+	now func() time.Time
+
+	// logWarn logs warnings, it can be replaced in tests.
+	logWarn func(msg string, ctx ...interface{})
+
+	settingsMx       sync.RWMutex // guards the settings below
+	rawTxValidation  bool
+	rawTxUnprotected bool
+	httpProxy        *url.URL
+	httpPool         *httpPoolSettings
+	fetchAllowlist   []string
+	fetchTimeout     time.Duration
+	stackTraces      bool
+	defaultCaller    string
+	watchOnly        map[gethcommon.Address]struct{}
+	nonceManagement  bool
+	requireResolver  func(moduleName string) (string, bool)
+	requiredCommands []string
+	logPageSize      uint64
+	approvalHandler  ApprovalHandler
+	persistenceDir   string
+	callTimeout      time.Duration
+	methodTimeouts   map[string]time.Duration
+	maxBatchSize     int
+	rpcObserver      rpc.CallObserver
+	web3JS           string // replaces the bundled web3.js if not empty
+	rateLimits       map[string]float64 // requests per second of methods
+	allowedMethods   []string
+	blockedMethods   []string
+
+	// retries of obtaining the RPC client, guarded by settingsMx
+	clientAcquireAttempts int
+	clientAcquireBackoff  time.Duration
+
+	bundles         map[string]string // JS bundles by names, guarded by settingsMx
+	cellWaitTimeout time.Duration     // guarded by settingsMx
+
+	// the dry-run mode, guarded by settingsMx
+	dryRun        bool
+	dryRunResults map[string]json.RawMessage // results by methods
+
+	accountCreation bool // enables status_createAccount, guarded by settingsMx
+	batchDedup      bool // de-duplicates requests of batches, guarded by settingsMx
+
+	emptyBaseJSWarned int32 // 1 if Parse warned about empty base JS
+
+	noncesMx sync.Mutex
+	nonces   map[gethcommon.Address]uint64 // next nonces of accounts
+
+	network       networkIDCache
+	blockNumber   blockNumberCache
+	responseCache responseCache
+	pool          cellPool
 }
 
 // New returns a new Jail.
@@ -59,42 +165,119 @@ func NewWithBaseJS(provider RPCClientProvider, code string) *Jail {
 		rpcClientProvider: provider,
 		baseJS:            code,
 		cells:             make(map[string]*Cell),
+		groups:            make(map[string]*cellGroup),
+		nonces:            make(map[gethcommon.Address]uint64),
+		rpcScheduler:      &rpcScheduler{},
+		blockNumber:       blockNumberCache{ttl: DefaultBlockNumberTTL},
+		logPageSize:       DefaultLogPageSize,
+		maxBatchSize:      DefaultMaxBatchSize,
+		cellWaitTimeout:   DefaultCellWaitTimeout,
+		now:               time.Now,
+		logWarn:           log.Warn,
 	}
 }
 
 // SetBaseJS sets initial JavaScript code loaded to each new cell.
 func (j *Jail) SetBaseJS(js string) {
+	j.settingsMx.Lock()
 	j.baseJS = js
+	j.settingsMx.Unlock()
+
+	// Pooled cells are initialized with the previous code.
+	j.drainPool()
 }
 
-// Stop stops jail and all assosiacted cells.
+// warnEmptyBaseJS logs a warning once if cells are parsed without base JS,
+// e.g. if the jail was created with New and SetBaseJS was never called.
+// Such cells work, but they lack status helpers the base JS provides,
+// so DApps relying on them are likely broken.
+func (j *Jail) warnEmptyBaseJS() {
+	j.settingsMx.RLock()
+	empty := j.baseJS == ""
+	j.settingsMx.RUnlock()
+
+	if empty && atomic.CompareAndSwapInt32(&j.emptyBaseJSWarned, 0, 1) {
+		j.logWarn("jail is not initialized with status JS, cells are parsed without it")
+	}
+}
+
+// SetWeb3JS replaces the bundled web3.js loaded to each new cell, e.g. to pin
+// a specific version. Like the bundled one, the code must provide
+// require('web3') and require('bignumber.js'). Parse fails if Web3
+// is not defined afterwards. An empty string restores the bundled web3.js.
+func (j *Jail) SetWeb3JS(js string) {
+	j.settingsMx.Lock()
+	j.web3JS = js
+	j.settingsMx.Unlock()
+
+	// Pooled cells are initialized with the previous code.
+	j.drainPool()
+}
+
+// Stop stops jail and all assosiacted cells. RPC requests of calls
+// in progress are aborted and the calls are waited for to finish.
+// The RPC client is obtained from the provider again when it's needed.
+// It's safe to call Stop on a jail which was never used.
 func (j *Jail) Stop() {
+	// The lock is held only to detach the cells, so that
+	// lookups are not blocked while cells are being stopped.
 	j.cellsMx.Lock()
-	defer j.cellsMx.Unlock()
+	cells := j.cells
+	// TODO(tiabc): Move this initialisation to a proper place.
+	j.cells = make(map[string]*Cell)
+	j.cellsMx.Unlock()
+
+	// Abort RPC requests of all cells first, so that they finish in parallel.
+	for _, cell := range cells {
+		cell.cancel()
+	}
 
-	for _, cell := range j.cells {
+	for _, cell := range cells {
+		cell.lockCalls()
 		cell.Stop() //nolint: errcheck
+		cell.unlockCalls()
 	}
 
-	// TODO(tiabc): Move this initialisation to a proper place.
-	j.cells = make(map[string]*Cell)
-}
+	j.SetPoolSize(0)
 
-// createCell creates a new cell if it does not exists.
-func (j *Jail) createCell(chatID string) (*Cell, error) {
-	j.cellsMx.Lock()
-	defer j.cellsMx.Unlock()
+	// The node is likely to be stopped or restarted.
+	j.InvalidateClient()
+}
 
-	if cell, ok := j.cells[chatID]; ok {
+// createCell creates a new cell if it does not exists. If initialized is true,
+// the cell is initialized with initCell before it's added to the jail.
+// The cell is created outside of the lock as it's a relatively slow operation.
+func (j *Jail) createCell(chatID string, initialized bool) (*Cell, error) {
+	if cell, err := j.cell(chatID); err == nil {
 		return cell, fmt.Errorf("cell with id '%s' already exists", chatID)
 	}
 
-	cell, err := NewCell(chatID)
+	var (
+		cell *Cell
+		err  error
+	)
+	if initialized {
+		cell, err = j.newInitializedCell(chatID)
+	} else {
+		cell, err = NewCell(chatID)
+	}
 	if err != nil {
 		return nil, err
 	}
+	cell.createdAt = j.now()
 
-	j.cells[chatID] = cell
+	j.cellsMx.Lock()
+	existing, ok := j.cells[chatID]
+	if !ok {
+		j.cells[chatID] = cell
+	}
+	j.cellsMx.Unlock()
+
+	// Another cell with the same ID was created concurrently.
+	if ok {
+		cell.Stop() //nolint: errcheck
+		return existing, fmt.Errorf("cell with id '%s' already exists", chatID)
+	}
 
 	return cell, nil
 }
@@ -102,7 +285,7 @@ func (j *Jail) createCell(chatID string) (*Cell, error) {
 // CreateCell creates a new cell. It returns an error
 // if a cell with a given ID already exists.
 func (j *Jail) CreateCell(chatID string) (common.JailCell, error) {
-	return j.createCell(chatID)
+	return j.createCell(chatID, false)
 }
 
 // initCell initializes a cell with default JavaScript handlers and user code.
@@ -116,28 +299,61 @@ func (j *Jail) initCell(cell *Cell) error {
 		return err
 	}
 
+	if err := registerConsole(cell); err != nil {
+		return err
+	}
+
+	if err := registerStatusFetch(j, cell); err != nil {
+		return err
+	}
+
+	if err := registerRequireResolver(j, cell); err != nil {
+		return err
+	}
+
+	if err := registerStatusEmit(cell); err != nil {
+		return err
+	}
+
+	j.settingsMx.RLock()
+	baseJS := j.baseJS
+	web3JS := j.web3JS
+	j.settingsMx.RUnlock()
+
+	custom := web3JS != ""
+	if !custom {
+		web3JS = web3Code
+	}
+
 	// Run some initial JS code to provide some global objects.
 	c := []string{
-		j.baseJS,
-		web3Code,
+		baseJS,
+		web3JS,
+		requireResolverCode,
 		web3InstanceCode,
+		unitsCode,
 	}
 
 	_, err := cell.Run(strings.Join(c, ";"))
+	if custom {
+		if web3, getErr := cell.Get("Web3"); getErr != nil || !web3.IsFunction() {
+			if err == nil {
+				err = errors.New("Web3 is not a function")
+			}
+			return fmt.Errorf("%s: %s", ErrInvalidWeb3JS, err)
+		}
+	}
+
 	return err
 }
 
 // CreateAndInitCell creates and initializes a new Cell.
 func (j *Jail) createAndInitCell(chatID string, code ...string) (*Cell, error) {
-	cell, err := j.createCell(chatID)
+	cell, err := j.createCell(chatID, true)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := j.initCell(cell); err != nil {
-		return nil, err
-	}
-
 	// Run custom user code
 	for _, js := range code {
 		_, err := cell.Run(js)
@@ -165,24 +381,110 @@ func (j *Jail) CreateAndInitCell(chatID string, code ...string) string {
 // New context executes provided JavaScript code, right after the initialization.
 // DEPRECATED in favour of CreateAndInitCell.
 func (j *Jail) Parse(chatID, code string) string {
+	return j.ParseWithOptions(chatID, defaultParseOptions(code))
+}
+
+// Reset replaces the code of an existing cell, e.g. when a DApp updates
+// its script. Unlike Parse, the cell keeps its group. A call in progress
+// finishes with the old code before the old VM is stopped. It returns
+// the catalog like Parse.
+func (j *Jail) Reset(chatID, code string) string {
 	cell, err := j.cell(chatID)
 	if err != nil {
-		// cell does not exist, so create and init it
-		cell, err = j.createAndInitCell(chatID, code)
-	} else {
-		// cell already exists, so just reinit it
-		err = j.initCell(cell)
+		return newJailErrorResponse(err)
+	}
+
+	return j.reparse(chatID, defaultParseOptions(code), cell.group())
+}
+
+// parse implements Parse. If group is not nil, the cell is put into the group.
+func (j *Jail) parse(chatID string, opts ParseOptions, group *cellGroup) string {
+	value, err := j.parseCell(chatID, opts, group)
+	if err != nil {
+		return j.newScriptErrorResponse(err)
+	}
+
+	return newJailResultResponse(value.String())
+}
+
+// parseCell works like parse, but it returns the catalog and errors
+// as they are.
+func (j *Jail) parseCell(chatID string, opts ParseOptions, group *cellGroup) (otto.Value, error) {
+	j.warnEmptyBaseJS()
+
+	if _, err := j.cell(chatID); err == nil {
+		return j.reparseCell(chatID, opts, group)
 	}
 
+	// cell does not exist, so create and init it
+	cell, err := j.createAndInitCell(chatID)
 	if err != nil {
-		return newJailErrorResponse(err)
+		return otto.UndefinedValue(), err
 	}
 
-	if _, err = cell.Run(code); err != nil {
+	cell.setGroup(group)
+	cell.setScriptHash(hashScript(opts.JS, opts.Bundles))
+
+	if _, err = j.runScript(cell, opts); err != nil {
+		return otto.UndefinedValue(), err
+	}
+
+	return j.catalogVariable(cell)
+}
+
+// reparse replaces an existing cell with a new one running the code.
+func (j *Jail) reparse(chatID string, opts ParseOptions, group *cellGroup) string {
+	value, err := j.reparseCell(chatID, opts, group)
+	if err != nil {
 		return newJailErrorResponse(err)
 	}
 
-	return j.makeCatalogVariable(cell)
+	return newJailResultResponse(value.String())
+}
+
+// reparseCell implements reparse.
+// The new cell is fully initialized before it's swapped in, so that
+// concurrent calls see either the old or the new cell, but never
+// a partially initialized one. If initialization fails, the existing
+// cell is kept.
+func (j *Jail) reparseCell(chatID string, opts ParseOptions, group *cellGroup) (otto.Value, error) {
+	cell, err := j.newInitializedCell(chatID)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	cell.setGroup(group)
+	cell.setScriptHash(hashScript(opts.JS, opts.Bundles))
+
+	if _, err := j.runScript(cell, opts); err != nil {
+		cell.Stop() //nolint: errcheck
+		return otto.UndefinedValue(), err
+	}
+
+	value, err := j.catalogVariable(cell)
+	if err != nil {
+		cell.Stop() //nolint: errcheck
+		return otto.UndefinedValue(), err
+	}
+
+	j.cellsMx.Lock()
+	replaced, ok := j.cells[chatID]
+	if ok {
+		cell.createdAt = replaced.createdAt
+		cell.setEnabled(replaced.enabled())
+	} else {
+		cell.createdAt = j.now()
+	}
+	j.cells[chatID] = cell
+	j.cellsMx.Unlock()
+
+	if ok {
+		// Wait for a call in progress before stopping the replaced cell.
+		replaced.lockCalls()
+		replaced.Stop() //nolint: errcheck
+		replaced.unlockCalls()
+	}
+
+	return value, nil
 }
 
 // makeCatalogVariable provides `catalog` as a global variable.
@@ -190,17 +492,62 @@ func (j *Jail) Parse(chatID, code string) string {
 // on a clojure side. Moving this into separate method to nuke it later
 // easier.
 func (j *Jail) makeCatalogVariable(cell *Cell) string {
-	_, err := cell.Run(`var catalog = JSON.stringify(_status_catalog)`)
+	value, err := j.catalogVariable(cell)
 	if err != nil {
 		return newJailErrorResponse(err)
 	}
 
+	return newJailResultResponse(value.String())
+}
+
+// catalogVariable implements makeCatalogVariable.
+func (j *Jail) catalogVariable(cell *Cell) (otto.Value, error) {
+	_, err := cell.Run(`var catalog = JSON.stringify(_status_catalog)`)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+
+	if err := j.checkRequiredCommands(cell); err != nil {
+		return otto.UndefinedValue(), err
+	}
+
 	value, err := cell.Get("catalog")
 	if err != nil {
-		return newJailErrorResponse(err)
+		return otto.UndefinedValue(), err
+	}
+
+	// JSON.stringify returns undefined for undefined or a function.
+	if value.IsUndefined() {
+		return otto.UndefinedValue(), ErrNoCatalog
+	}
+
+	return value, nil
+}
+
+// Catalog returns the catalog of a cell set by Parse or CreateAndInitCell,
+// decoded from JSON. ErrNoCatalog is returned if the catalog isn't set,
+// e.g. the cell was created with CreateCell.
+func (j *Jail) Catalog(chatID string) (map[string]interface{}, error) {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := cell.Get("catalog")
+	if err != nil {
+		return nil, err
+	}
+
+	if !value.IsString() {
+		return nil, ErrNoCatalog
 	}
 
-	return newJailResultResponse(value)
+	var catalog map[string]interface{}
+	if err := json.Unmarshal([]byte(value.String()), &catalog); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %v", err)
+	}
+
+	return catalog, nil
 }
 
 func (j *Jail) cell(chatID string) (*Cell, error) {
@@ -209,7 +556,7 @@ func (j *Jail) cell(chatID string) (*Cell, error) {
 
 	cell, ok := j.cells[chatID]
 	if !ok {
-		return nil, fmt.Errorf("cell '%s' not found", chatID)
+		return nil, &CellNotFoundError{ChatID: chatID}
 	}
 
 	return cell, nil
@@ -221,6 +568,96 @@ func (j *Jail) Cell(chatID string) (common.JailCell, error) {
 	return j.cell(chatID)
 }
 
+// SetCellEnabled enables or disables a cell. Calls of a disabled cell
+// are rejected with ErrCellDisabled and its RPC requests get error
+// responses, but the cell keeps its state.
+func (j *Jail) SetCellEnabled(chatID string, enabled bool) error {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return err
+	}
+
+	cell.setEnabled(enabled)
+
+	return nil
+}
+
+// RemoveCell stops a cell and removes it from the jail to release its VM.
+// RPC requests of a call in progress are aborted and the call is waited
+// for to finish. An error is returned
+// if the cell does not exist, e.g. it was already removed.
+func (j *Jail) RemoveCell(chatID string) error {
+	j.cellsMx.Lock()
+	cell, ok := j.cells[chatID]
+	if ok {
+		delete(j.cells, chatID)
+	}
+	j.cellsMx.Unlock()
+
+	if !ok {
+		return &CellNotFoundError{ChatID: chatID}
+	}
+
+	// Abort RPC requests in progress, so that a call doesn't block removal.
+	cell.cancel()
+
+	cell.lockCalls()
+	defer cell.unlockCalls()
+
+	return cell.Stop()
+}
+
+// Cells returns sorted IDs of the existing cells.
+func (j *Jail) Cells() []string {
+	j.cellsMx.RLock()
+	defer j.cellsMx.RUnlock()
+
+	chatIDs := make([]string, 0, len(j.cells))
+	for chatID := range j.cells {
+		chatIDs = append(chatIDs, chatID)
+	}
+	sort.Strings(chatIDs)
+
+	return chatIDs
+}
+
+// CellInfo returns when a cell with chatID was created and whether
+// a call of the cell is in progress.
+func (j *Jail) CellInfo(chatID string) (createdAt time.Time, busy bool, err error) {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return cell.createdAt, cell.busy(), nil
+}
+
+// CellUptime returns how long ago a cell with chatID was created.
+func (j *Jail) CellUptime(chatID string) (time.Duration, error) {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return 0, err
+	}
+
+	return j.now().Sub(cell.createdAt), nil
+}
+
+// CellStats returns the total time calls of a cell with chatID waited
+// for a call in progress to finish, and the number of calls which timed
+// out waiting, e.g. because the deadline of CallContext was exceeded.
+// They show whether a single chat is a bottleneck.
+func (j *Jail) CellStats(chatID string) (waitTotal time.Duration, acquireTimeouts int, err error) {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	waitTotal = time.Duration(atomic.LoadInt64(&cell.callWait))
+	acquireTimeouts = int(atomic.LoadInt32(&cell.callTimeouts))
+
+	return waitTotal, acquireTimeouts, nil
+}
+
 // Execute allows to run arbitrary JS code within a cell.
 func (j *Jail) Execute(chatID, code string) string {
 	cell, err := j.cell(chatID)
@@ -245,36 +682,363 @@ func (j *Jail) Execute(chatID, code string) string {
 // For instance:
 //   `["prop1", "prop2"]` is translated to `_status_catalog["prop1"]["prop2"]`.
 func (j *Jail) Call(chatID, commandPath, args string) string {
+	return j.CallContext(context.Background(), chatID, commandPath, args)
+}
+
+// CallWithArgs works like Call, but args are marshalled to JSON,
+// so that Go callers don't have to build JSON by hand.
+func (j *Jail) CallWithArgs(chatID, commandPath string, args interface{}) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return newJailErrorResponse(fmt.Errorf("failed to marshal args: %s", err))
+	}
+
+	return j.Call(chatID, commandPath, string(data))
+}
+
+// CallRaw works like Call, but it returns the result of the call,
+// formatted like in Call responses, and errors as Go errors.
+// A CellNotFoundError is returned if the cell does not exist.
+func (j *Jail) CallRaw(chatID, commandPath, args string) (string, error) {
 	cell, err := j.cell(chatID)
 	if err != nil {
-		return newJailErrorResponse(err)
+		return "", err
+	}
+
+	value, err := j.callCell(cell, commandPath, args, callOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return value.String(), nil
+}
+
+// callOptions defines how a cell executes a call.
+type callOptions struct {
+	// high makes RPC requests sent during the call high-priority.
+	high bool
+	// events collects events emitted during the call, if not nil.
+	events *[]string
+	// ctx aborts RPC requests sent during the call when cancelled, if not nil.
+	ctx context.Context
+}
+
+// callCell executes the `call` function within a cell and formats its result.
+func (j *Jail) callCell(cell *Cell, commandPath, args string, opts callOptions) (otto.Value, error) {
+	if !cell.enabled() {
+		return otto.UndefinedValue(), ErrCellDisabled
+	}
+
+	atomic.AddInt32(&cell.calls, 1)
+	defer atomic.AddInt32(&cell.calls, -1)
+
+	ctx := opts.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Calls are serialized by the VM anyway, so holding callSem
+	// makes sure that the options apply to a single call.
+	caller := goroutineID()
+	if reentrant(ctx, cell) || cell.calledBy(caller) {
+		return otto.UndefinedValue(), ErrReentrantCall
 	}
+	if err := j.lockCell(ctx, cell); err != nil {
+		return otto.UndefinedValue(), err
+	}
+	defer cell.unlockCalls()
+
+	cell.setCallOwner(caller)
+	defer cell.setCallOwner(0)
+
+	cell.setHighPriority(opts.high)
+	defer cell.setHighPriority(false)
+
+	cell.setEventCollector(opts.events)
+	defer cell.setEventCollector(nil)
+
+	cell.setCallContext(markCall(ctx, cell))
+	defer cell.setCallContext(nil)
 
 	value, err := cell.Call("call", nil, commandPath, args)
 	if err != nil {
-		return newJailErrorResponse(err)
+		return value, err
 	}
 
-	return newJailResultResponse(value)
+	return formatCallResult(cell, value)
+}
+
+// EnableStackTraces enables or disables JavaScript stack traces
+// in error responses returned by Call and Parse, like
+// {"error": "some error", "data": {"stack": "some error\n    at ..."}}.
+// Stack traces should be disabled in production.
+func (j *Jail) EnableStackTraces(enabled bool) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.stackTraces = enabled
+}
+
+// CallWithStack works like Call.
+//
+// Deprecated: Call includes stacks of JavaScript errors if stack traces
+// are enabled, see EnableStackTraces.
+func (j *Jail) CallWithStack(chatID, commandPath, args string) string {
+	return j.Call(chatID, commandPath, args)
 }
 
 // RPCClient returns an rpc.Client.
+//
+// The client is obtained from the provider each time. If it differs from
+// the previously used one, for instance, after the node was restarted
+// with another network, the jail's client settings are applied to it.
+// If an upstream is set with SetUpstreamRPC, a client routing requests
+// to the upstream is returned instead.
 func (j *Jail) RPCClient() *rpc.Client {
-	if j.rpcClientProvider == nil {
+	j.clientMx.Lock()
+	provider := j.rpcClientProvider
+	j.clientMx.Unlock()
+
+	if provider == nil {
+		return nil
+	}
+
+	client := j.acquireClient(provider)
+	if client == nil {
 		return nil
 	}
 
-	return j.rpcClientProvider.RPCClient()
+	j.clientMx.Lock()
+	var stale *rpc.Client
+	if client != j.client {
+		j.client = client
+		stale, j.upstreamClient = j.upstreamClient, nil
+		if err := j.configureClient(client); err != nil {
+			log.Warn("failed to configure RPC client", "err", err)
+		}
+	}
+	upstreamURL, upstream := j.upstreamURL, j.upstreamClient
+	j.clientMx.Unlock()
+
+	if stale != nil {
+		stale.Close()
+	}
+
+	if upstreamURL == "" {
+		return client
+	}
+	if upstream != nil {
+		return upstream
+	}
+
+	return j.dialUpstreamRPC(client, upstreamURL)
+}
+
+// SetRPCClientProvider replaces the provider of the RPC client, e.g. with one
+// returning a client of a mock server in tests. Nil removes the provider
+// and requests fail with ErrNoRPCClient.
+func (j *Jail) SetRPCClientProvider(provider RPCClientProvider) {
+	j.clientMx.Lock()
+	defer j.clientMx.Unlock()
+
+	j.rpcClientProvider = provider
+	j.client = nil
+}
+
+// InvalidateClient forgets the previously used RPC client,
+// so that the jail's client settings are applied again
+// to the client obtained on the next call.
+func (j *Jail) InvalidateClient() {
+	j.clientMx.Lock()
+	defer j.clientMx.Unlock()
+
+	j.client = nil
+}
+
+// SendRaw sends a raw JSON-RPC request or batch from Go code, e.g. a host
+// which doesn't run a cell, and returns the raw JSON-RPC response.
+// Requests go through the same processing as requests sent by cells,
+// like method access checks, nonce management and caching.
+// Errors preventing a response from being obtained, like ErrNoRPCClient,
+// are returned as Go errors.
+func (j *Jail) SendRaw(request string) (string, error) {
+	response, err := j.sendRPCCall(nil, request)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal response: %s", err)
+	}
+
+	return string(restoreResponseIDs(request, data)), nil
+}
+
+// sendRPCCall executes a raw JSON-RPC request on behalf of a cell.
+// The cell can be nil if the request doesn't originate from a cell.
+func (j *Jail) sendRPCCall(cell *Cell, request string) (interface{}, error) {
+	ctx := context.Background()
+	if cell != nil {
+		if callCtx := cell.callContext(); callCtx != nil {
+			ctx = callCtx
+		}
+	}
+
+	high := cell != nil && cell.highPriority()
+	return j.sendRPCCallContext(ctx, cell, request, high)
+}
+
+// sendRPCCallWithPriority works like sendRPCCall, but the priority
+// of the request is given explicitly.
+func (j *Jail) sendRPCCallWithPriority(cell *Cell, request string, high bool) (interface{}, error) {
+	return j.sendRPCCallContext(context.Background(), cell, request, high)
+}
+
+// policyResponse applies policies of the jail and the cell to a raw JSON-RPC
+// payload of the cell, like allowed methods and rate limits. It returns
+// a response or an error if the payload must not be sent to the node,
+// and false otherwise. Requests and subscriptions go through it alike.
+func (j *Jail) policyResponse(cell *Cell, request string) (interface{}, bool, error) {
+	if cell != nil && !cell.enabled() {
+		response, err := newErrorResponses(request, cellDisabledErrorCode, ErrCellDisabled)
+		return response, true, err
+	}
+
+	if response, ok := j.methodNotAllowedResponse(cell, request); ok {
+		return response, true, nil
+	}
+
+	if response, ok := j.rateLimitedResponse(cell, request); ok {
+		return response, true, nil
+	}
+
+	// Account creation is handled before recording requests,
+	// so that passwords are never recorded.
+	if response, ok := j.createAccountResponse(request); ok {
+		return response, true, nil
+	}
+
+	if response, ok := j.dryRunResponse(cell, request); ok {
+		return response, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// callRawContext sends a raw JSON-RPC payload with client once a slot
+// is free, see SetMaxConcurrentRPC. The slot is held only for the round
+// trip, so that requests waiting for something else, like an approval,
+// don't take slots of other requests.
+func (j *Jail) callRawContext(ctx context.Context, cell *Cell, client *rpc.Client, request string, high bool) (string, error) {
+	if err := j.rpcScheduler.acquire(ctx, high); err != nil {
+		return "", err
+	}
+	defer j.rpcScheduler.release()
+
+	ctx, cancel := j.requestContext(ctx, cell, request)
+	defer cancel()
+
+	return client.CallRawContext(j.withRPCObserver(ctx), request), nil
 }
 
-// sendRPCCall executes a raw JSON-RPC request.
-func (j *Jail) sendRPCCall(request string) (interface{}, error) {
+// sendRPCCallContext works like sendRPCCallWithPriority, but the request
+// is aborted when ctx is cancelled, also while it waits for a free slot.
+func (j *Jail) sendRPCCallContext(ctx context.Context, cell *Cell, request string, high bool) (interface{}, error) {
+	if response, ok := malformedRequestResponse(request); ok {
+		return response, nil
+	}
+
+	if response, ok := j.oversizedBatchResponse(request); ok {
+		return response, nil
+	}
+
+	if response, ok := j.unsubscribeResponse(cell, request); ok {
+		return response, nil
+	}
+
+	if callsMethod(request, subscribeMethod) {
+		return newErrorResponses(request, subscriptionErrorCode, ErrSubscribeRequiresCallback)
+	}
+
+	// Signing requests of watch-only accounts are rejected before they
+	// are recorded in the dry-run mode or wait for anything.
+	if err := j.checkWatchOnly(request); err != nil {
+		return newErrorResponses(request, watchOnlyErrorCode, err)
+	}
+
+	if response, ok, err := j.policyResponse(cell, request); ok || err != nil {
+		return response, err
+	}
+
+	request, err := j.injectDefaultCaller(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transactions are approved before anything else is done for them,
+	// so that a pending prompt doesn't hold resources of other requests.
+	approved, err := j.approveTransactions(cell, request)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		return newErrorResponses(request, userRejectedErrorCode, ErrUserRejected)
+	}
+
 	client := j.RPCClient()
 	if client == nil {
 		return nil, ErrNoRPCClient
 	}
 
-	rawResponse := client.CallRaw(request)
+	if j.rawTxValidationEnabled() {
+		if err := j.validateRawTransactions(client, request); err != nil {
+			return nil, err
+		}
+	}
+
+	var nonceReservations []nonceReservation
+	if j.nonceManagementEnabled() {
+		request, nonceReservations, err = j.injectNonces(client, request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		cacheSlot responseCacheSlot
+		cacheable bool
+	)
+	if slot, resolved, ok := j.responseCacheKey(client, request); ok {
+		if response, ok := j.cachedResponse(slot, request); ok {
+			return response, nil
+		}
+		cacheSlot, request, cacheable = slot, resolved, true
+	}
+
+	callRequest := request
+	var dedupSlots []int
+	if j.batchDedupEnabled() {
+		if batch, slots, ok := dedupBatch(request); ok {
+			callRequest, dedupSlots = batch, slots
+		}
+	}
+
+	rawResponse, err := j.callRawContext(ctx, cell, client, callRequest, high)
+	if err != nil {
+		for _, reservation := range nonceReservations {
+			j.releaseNonce(reservation)
+		}
+		return nil, err
+	}
+	if dedupSlots != nil {
+		rawResponse = expandBatchResponse(request, dedupSlots, rawResponse)
+	}
+	j.resyncFailedNonces(client, nonceReservations, rawResponse)
+
+	if cacheable {
+		j.cacheResponse(client, cacheSlot, rawResponse)
+	}
 
 	var response interface{}
 	if err := json.Unmarshal([]byte(rawResponse), &response); err != nil {
@@ -300,9 +1064,40 @@ func newJailErrorResponse(err error) string {
 	return string(rawResponse)
 }
 
+// newScriptErrorResponse returns an error of a failed script. If stack
+// traces are enabled and err is a JavaScript error, the stack is included
+// in the data of the error: {"error": "some error", "data": {"stack": "..."}}.
+func (j *Jail) newScriptErrorResponse(err error) string {
+	j.settingsMx.RLock()
+	stackTraces := j.stackTraces
+	j.settingsMx.RUnlock()
+
+	jsErr, ok := err.(*otto.Error)
+	if !stackTraces || !ok {
+		return newJailErrorResponse(err)
+	}
+
+	response := struct {
+		Error string `json:"error"`
+		Data  struct {
+			Stack string `json:"stack"`
+		} `json:"data"`
+	}{
+		Error: err.Error(),
+	}
+	response.Data.Stack = jsErr.String()
+
+	rawResponse, err := json.Marshal(response)
+	if err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	return string(rawResponse)
+}
+
 // newJailResultResponse returns a string that is a valid JavaScript code.
-// Marshaling is not required as result.String() produces a string
-// that is a valid JavaScript code.
-func newJailResultResponse(result otto.Value) string {
-	return `{"result": ` + result.String() + `}`
+// Marshaling is not required as result is expected to be produced
+// by otto.Value.String(), which is a valid JavaScript code.
+func newJailResultResponse(result string) string {
+	return `{"result": ` + result + `}`
 }
@@ -1,10 +1,21 @@
 package jail
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/rpc"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -16,6 +27,73 @@ func (p testRPCClientProvider) RPCClient() *rpc.Client {
 	return p.rpcClient
 }
 
+// testRPCHandler returns a result or an error for a JSON-RPC method.
+type testRPCHandler func(method string, params []json.RawMessage) (interface{}, error)
+
+// newTestRPCServer starts a JSON-RPC server responding with the results of handler.
+func newTestRPCServer(handler testRPCHandler) *httptest.Server {
+	return httptest.NewServer(newTestRPCHTTPHandler(handler))
+}
+
+// newTestRPCHTTPHandler returns http.Handler serving single and batch
+// JSON-RPC requests with the results of handler.
+func newTestRPCHTTPHandler(handler testRPCHandler) http.HandlerFunc {
+	respond := func(req rpcRequest) map[string]interface{} {
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		result, err := handler(req.Method, req.Params)
+		if err != nil {
+			code := -32000
+			if rpcErr, ok := err.(gethrpc.Error); ok {
+				code = rpcErr.ErrorCode()
+			}
+			response["error"] = map[string]interface{}{"code": code, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		return response
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		requests, err := decodeRequests(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var responses []map[string]interface{}
+		for _, req := range requests {
+			responses = append(responses, respond(req))
+		}
+
+		if isBatchRequest(string(body)) {
+			json.NewEncoder(w).Encode(responses) //nolint: errcheck
+			return
+		}
+		json.NewEncoder(w).Encode(responses[0]) //nolint: errcheck
+	}
+}
+
+// newTestRPCClientProvider returns a provider with rpc.Client connected to url.
+func newTestRPCClientProvider(url string) (*testRPCClientProvider, error) {
+	gethClient, err := gethrpc.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := rpc.NewClient(gethClient, params.UpstreamRPCConfig{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &testRPCClientProvider{client}, nil
+}
+
 func TestJailTestSuite(t *testing.T) {
 	suite.Run(t, new(JailTestSuite))
 }
@@ -58,9 +136,29 @@ func (s *JailTestSuite) TestJailGetCell() {
 	s.NotNil(cell)
 }
 
+func (s *JailTestSuite) TestJailCellUptime() {
+	now := time.Now()
+	s.Jail.now = func() time.Time { return now }
+
+	_, err := s.Jail.CellUptime("cell1")
+	s.EqualError(err, "cell 'cell1' not found")
+
+	_, err = s.Jail.CreateCell("cell1")
+	s.NoError(err)
+
+	uptime, err := s.Jail.CellUptime("cell1")
+	s.NoError(err)
+	s.Equal(time.Duration(0), uptime)
+
+	now = now.Add(time.Minute)
+	uptime, err = s.Jail.CellUptime("cell1")
+	s.NoError(err)
+	s.Equal(time.Minute, uptime)
+}
+
 func (s *JailTestSuite) TestJailInitCell() {
 	// InitCell on an existing cell.
-	cell, err := s.Jail.createCell("cell1")
+	cell, err := s.Jail.createCell("cell1", false)
 	s.NoError(err)
 	err = s.Jail.initCell(cell)
 	s.NoError(err)
@@ -102,7 +200,7 @@ func (s *JailTestSuite) TestJailCall() {
 }
 
 func (s *JailTestSuite) TestMakeCatalogVariable() {
-	cell, err := s.Jail.createCell("cell1")
+	cell, err := s.Jail.createCell("cell1", false)
 	s.NoError(err)
 
 	// no `_status_catalog` variable
@@ -144,7 +242,7 @@ func (s *JailTestSuite) TestExecute() {
 	response := s.Jail.Execute("cell1", "('some string')")
 	s.Equal(`{"error":"cell 'cell1' not found"}`, response)
 
-	_, err := s.Jail.createCell("cell1")
+	_, err := s.Jail.createCell("cell1", false)
 	s.NoError(err)
 
 	// cell exists
@@ -154,3 +252,766 @@ func (s *JailTestSuite) TestExecute() {
 	`)
 	s.Equal(`{"test":true}`, response)
 }
+
+func (s *JailTestSuite) TestJailLookupsDuringSlowSend() {
+	receivedc := make(chan struct{}, 1)
+	releasec := make(chan struct{})
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		receivedc <- struct{}{}
+		<-releasec
+		return true, nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	s.NoError(err)
+	s.Jail = New(provider)
+
+	cell, err := s.Jail.createAndInitCell("cell1")
+	s.NoError(err)
+	_, err = s.Jail.createAndInitCell("cell2")
+	s.NoError(err)
+
+	sendDone := make(chan struct{})
+	go func() {
+		defer close(sendDone)
+		_, err := cell.Run("web3.eth.syncing")
+		s.NoError(err)
+	}()
+	<-receivedc
+
+	// While cell1 is blocked in a send, other cells are available.
+	lookupsDone := make(chan struct{})
+	go func() {
+		defer close(lookupsDone)
+
+		_, err := s.Jail.Cell("cell2")
+		s.NoError(err)
+		_, err = s.Jail.CreateCell("cell3")
+		s.NoError(err)
+		s.Equal(`3`, s.Jail.Execute("cell2", `1 + 2`))
+	}()
+
+	select {
+	case <-lookupsDone:
+	case <-time.After(time.Second):
+		s.Fail("lookups blocked by an in-flight send")
+	}
+
+	close(releasec)
+	<-sendDone
+}
+
+func (s *JailTestSuite) TestJailCallWithStack() {
+	cell, err := s.Jail.CreateCell("cell1")
+	s.NoError(err)
+
+	_, err = cell.Run(`
+		function inner() { throw new Error("inner failed"); }
+		function outer() { inner(); }
+		function call(path, args) { outer(); }
+	`)
+	s.NoError(err)
+
+	// stack traces are disabled by default
+	response := s.Jail.CallWithStack("cell1", `["command"]`, `{}`)
+	s.Equal(`{"error":"Error: inner failed"}`, response)
+
+	s.Jail.EnableStackTraces(true)
+	response = s.Jail.CallWithStack("cell1", `["command"]`, `{}`)
+
+	var result struct {
+		Error string `json:"error"`
+		Data  struct {
+			Stack string `json:"stack"`
+		} `json:"data"`
+	}
+	s.NoError(json.Unmarshal([]byte(response), &result))
+	s.Equal("Error: inner failed", result.Error)
+	s.Contains(result.Data.Stack, "at inner")
+	s.Contains(result.Data.Stack, "at outer")
+	s.Contains(result.Data.Stack, "at call")
+
+	// non-JavaScript errors have no stack
+	response = s.Jail.CallWithStack("cell2", `["command"]`, `{}`)
+	s.Equal(`{"error":"cell 'cell2' not found"}`, response)
+}
+
+func (s *JailTestSuite) TestJailErrorStack() {
+	code := `
+		function inner() { throw new Error("inner failed"); }
+		function call(path, args) { inner(); }
+		var _status_catalog = {};
+	`
+	s.Equal(`{"result": {}}`, s.Jail.Parse("cell1", code))
+
+	// stack traces are disabled by default
+	s.Equal(`{"error":"Error: inner failed"}`, s.Jail.Call("cell1", `["command"]`, `{}`))
+
+	s.Jail.EnableStackTraces(true)
+
+	var result struct {
+		Error string `json:"error"`
+		Data  struct {
+			Stack string `json:"stack"`
+		} `json:"data"`
+	}
+	response := s.Jail.Call("cell1", `["command"]`, `{}`)
+	s.NoError(json.Unmarshal([]byte(response), &result))
+	s.Equal("Error: inner failed", result.Error)
+	s.Contains(result.Data.Stack, "at inner")
+	s.Contains(result.Data.Stack, "at call")
+
+	// as well as errors of scripts
+	response = s.Jail.Parse("cell2", `function init() { null.x; }; init();`)
+	s.NoError(json.Unmarshal([]byte(response), &result))
+	s.Contains(result.Error, "TypeError")
+	s.Contains(result.Data.Stack, "at init")
+
+	// non-JavaScript errors have no data
+	s.Equal(`{"error":"cell 'cell3' not found"}`, s.Jail.Call("cell3", `["command"]`, `{}`))
+}
+
+func TestSetCellEnabled(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		var counter = 0;
+		function call(pathStr, paramsStr) {
+			return ++counter;
+		}
+	`)
+	require.NotContains(t, response, "error")
+	cell, err := jail.cell("cell1")
+	require.NoError(t, err)
+
+	require.Equal(t, `{"result": 1}`, jail.Call("cell1", `["commands", "count"]`, `{}`))
+
+	require.NoError(t, jail.SetCellEnabled("cell1", false))
+	require.Equal(t, `{"error":"cell disabled"}`, jail.Call("cell1", `["commands", "count"]`, `{}`))
+
+	sendResponse, err := jail.sendRPCCall(cell, `[{"jsonrpc":"2.0","id":7,"method":"eth_blockNumber","params":[]}]`)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      float64(7),
+			"error": map[string]interface{}{
+				"code":    float64(-32000),
+				"message": "cell disabled",
+			},
+		},
+	}, sendResponse)
+
+	// the state is preserved
+	require.NoError(t, jail.SetCellEnabled("cell1", true))
+	require.Equal(t, `{"result": 2}`, jail.Call("cell1", `["commands", "count"]`, `{}`))
+
+	sendResponse, err = jail.sendRPCCall(cell, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, "0x1", sendResponse.(map[string]interface{})["result"])
+
+	require.EqualError(t, jail.SetCellEnabled("cell2", false), "cell 'cell2' not found")
+}
+
+func TestRequireCommands(t *testing.T) {
+	jail := New(nil)
+	jail.RequireCommands([]string{"init", "send"})
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {commands: {send: function() {}}};
+	`)
+	require.Equal(t, `{"error":"`+ErrMissingCommands.Error()+`: init"}`, response)
+
+	response = jail.Parse("cell2", `
+		var _status_catalog = {init: function() {}, commands: {send: function() {}}};
+	`)
+	require.NotContains(t, response, "error")
+}
+
+func TestParseReplacesCellAtomically(t *testing.T) {
+	// Base JS is run before the user code, so a call executed
+	// against a partially initialized cell returns "partial".
+	jail := NewWithBaseJS(nil, `var version = "partial";`)
+
+	parseCode := func(version int) string {
+		return fmt.Sprintf(`
+			var _status_catalog = {};
+			var version = "%d";
+			function call(pathStr, paramsStr) {
+				return version;
+			}
+		`, version)
+	}
+
+	response := jail.Parse("cell1", parseCode(0))
+	require.NotContains(t, response, "error")
+
+	const iterations = 20
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= iterations; i++ {
+			response := jail.Parse("cell1", parseCode(i))
+			require.NotContains(t, response, "error")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations*5; i++ {
+			response := jail.Call("cell1", `["commands", "version"]`, `{}`)
+			require.NotContains(t, response, "partial")
+			require.NotContains(t, response, "error")
+		}
+	}()
+	wg.Wait()
+
+	require.Equal(t, fmt.Sprintf(`{"result": %d}`, iterations), jail.Call("cell1", `["commands", "version"]`, `{}`))
+
+	// a failed parse keeps the existing cell
+	response = jail.Parse("cell1", `throw new Error("broken")`)
+	require.Contains(t, response, "broken")
+	require.Equal(t, fmt.Sprintf(`{"result": %d}`, iterations), jail.Call("cell1", `["commands", "version"]`, `{}`))
+}
+
+func TestConcurrentParseAndCall(t *testing.T) {
+	jail := New(nil)
+
+	const cells = 50
+
+	var wg sync.WaitGroup
+	wg.Add(cells)
+	for i := 0; i < cells; i++ {
+		go func(i int) {
+			defer wg.Done()
+
+			chatID := fmt.Sprintf("cell%d", i)
+			response := jail.Parse(chatID, fmt.Sprintf(`
+				var _status_catalog = {};
+				function call(pathStr, paramsStr) {
+					return %d;
+				}
+			`, i))
+			require.NotContains(t, response, "error")
+
+			require.Equal(t, fmt.Sprintf(`{"result": %d}`, i), jail.Call(chatID, `["commands", "get"]`, `{}`))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < cells; i++ {
+		_, err := jail.Cell(fmt.Sprintf("cell%d", i))
+		require.NoError(t, err)
+	}
+}
+
+func TestRemoveCell(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return 1;
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	require.NoError(t, jail.RemoveCell("cell1"))
+	_, err := jail.Cell("cell1")
+	require.EqualError(t, err, "cell 'cell1' not found")
+	require.Equal(t, `{"error":"cell 'cell1' not found"}`, jail.Call("cell1", `["commands", "get"]`, `{}`))
+
+	// removing twice fails
+	require.EqualError(t, jail.RemoveCell("cell1"), "cell 'cell1' not found")
+	require.EqualError(t, jail.RemoveCell("cell2"), "cell 'cell2' not found")
+
+	// the chat ID can be parsed again
+	response = jail.Parse("cell1", `var _status_catalog = {};`)
+	require.NotContains(t, response, "error")
+}
+
+func TestCellsAndCellInfo(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		<-unblock
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	require.Empty(t, jail.Cells())
+
+	now := time.Now()
+	jail.now = func() time.Time { return now }
+
+	for _, chatID := range []string{"cell2", "cell1"} {
+		response := jail.Parse(chatID, `
+			var _status_catalog = {};
+			function call(pathStr, paramsStr) {
+				return web3.eth.blockNumber;
+			}
+		`)
+		require.NotContains(t, response, "error")
+	}
+	require.Equal(t, []string{"cell1", "cell2"}, jail.Cells())
+
+	createdAt, busy, err := jail.CellInfo("cell1")
+	require.NoError(t, err)
+	require.Equal(t, now, createdAt)
+	require.False(t, busy)
+
+	done := make(chan string)
+	go func() {
+		done <- jail.Call("cell1", `["commands", "blockNumber"]`, `{}`)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, busy, err = jail.CellInfo("cell1")
+		require.NoError(t, err)
+		if busy || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.True(t, busy)
+
+	_, busy, err = jail.CellInfo("cell2")
+	require.NoError(t, err)
+	require.False(t, busy)
+
+	close(unblock)
+	require.Equal(t, `{"result": 1}`, <-done)
+
+	_, busy, err = jail.CellInfo("cell1")
+	require.NoError(t, err)
+	require.False(t, busy)
+
+	_, _, err = jail.CellInfo("cell3")
+	require.EqualError(t, err, "cell 'cell3' not found")
+}
+
+func TestCallRaw(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			if (JSON.parse(paramsStr).fail) {
+				throw new Error("failed");
+			}
+			return 42;
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	result, err := jail.CallRaw("cell1", `["commands", "get"]`, `{}`)
+	require.NoError(t, err)
+	require.Equal(t, "42", result)
+
+	_, err = jail.CallRaw("cell1", `["commands", "get"]`, `{"fail": true}`)
+	require.EqualError(t, err, "Error: failed")
+
+	_, err = jail.CallRaw("cell2", `["commands", "get"]`, `{}`)
+	require.EqualError(t, err, "cell 'cell2' not found")
+	require.True(t, errors.Is(err, ErrCellNotFound))
+	require.Equal(t, "cell2", err.(*CellNotFoundError).ChatID)
+
+	// Call wraps CallRaw
+	require.Equal(t, `{"result": 42}`, jail.Call("cell1", `["commands", "get"]`, `{}`))
+	require.Equal(t, `{"error":"cell 'cell2' not found"}`, jail.Call("cell2", `["commands", "get"]`, `{}`))
+}
+
+func TestCallRecoversFromPanics(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return crash();
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	cell, err := jail.Cell("cell1")
+	require.NoError(t, err)
+	require.NoError(t, cell.Set("crash", func(call otto.FunctionCall) otto.Value {
+		var m map[string]int
+		m["crash"] = 1
+		return otto.UndefinedValue()
+	}))
+
+	require.Equal(t, `{"error":"assignment to entry in nil map"}`, jail.Call("cell1", `["commands", "get"]`, `{}`))
+
+	// the cell is still usable
+	value, err := cell.Run(`1 + 1`)
+	require.NoError(t, err)
+	require.Equal(t, "2", value.String())
+}
+
+func TestParseErrors(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `var _status_catalog = {;`)
+	require.Contains(t, response, `{"error":"(anonymous): Line 1:`)
+	require.NotContains(t, response, "result")
+
+	response = jail.Parse("cell2", `var foo = 1;`)
+	require.Equal(t, `{"error":"ReferenceError: '_status_catalog' is not defined"}`, response)
+
+	response = jail.Parse("cell3", `var _status_catalog;`)
+	require.Equal(t, `{"error":"`+ErrNoCatalog.Error()+`"}`, response)
+
+	response = jail.Parse("cell4", `throw new Error("broken")`)
+	require.Equal(t, `{"error":"Error: broken"}`, response)
+}
+
+func TestSetRPCClientProvider(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "eth_blockNumber" {
+			return "0x10", nil
+		}
+		return nil, errors.New("method not supported")
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(nil)
+	_, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.Equal(t, ErrNoRPCClient, err)
+
+	jail.SetRPCClientProvider(provider)
+
+	response, err := jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, "0x10", response.(map[string]interface{})["result"])
+
+	response, err = jail.sendRPCCall(nil, `[`+testBlockNumberRequest+`,{"jsonrpc":"2.0","id":2,"method":"eth_unknown","params":[]}]`)
+	require.NoError(t, err)
+	responses := response.([]interface{})
+	require.Len(t, responses, 2)
+	require.Equal(t, "0x10", responses[0].(map[string]interface{})["result"])
+	rpcErr := responses[1].(map[string]interface{})["error"].(map[string]interface{})
+	require.Equal(t, "method not supported", rpcErr["message"])
+
+	// requests of cells use the provider as well
+	jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return web3.eth.blockNumber;
+		}
+	`)
+	require.Equal(t, `{"result": 16}`, jail.Call("cell1", `["commands", "blockNumber"]`, `{}`))
+
+	jail.SetRPCClientProvider(nil)
+	_, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.Equal(t, ErrNoRPCClient, err)
+}
+
+func TestCatalog(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `var _status_catalog = {commands: {send: {title: "Send"}}, version: 2};`)
+	require.NotContains(t, response, "error")
+
+	catalog, err := jail.Catalog("cell1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"commands": map[string]interface{}{
+			"send": map[string]interface{}{"title": "Send"},
+		},
+		"version": float64(2),
+	}, catalog)
+
+	_, err = jail.Catalog("cell2")
+	require.True(t, errors.Is(err, ErrCellNotFound))
+
+	_, err = jail.CreateCell("cell2")
+	require.NoError(t, err)
+	_, err = jail.Catalog("cell2")
+	require.Equal(t, ErrNoCatalog, err)
+
+	response = jail.Parse("cell3", `var _status_catalog = "commands";`)
+	require.NotContains(t, response, "error")
+	_, err = jail.Catalog("cell3")
+	require.Error(t, err)
+}
+
+func TestJailsAreIndependent(t *testing.T) {
+	jail1 := NewWithBaseJS(nil, `var network = "mainnet";`)
+	jail2 := NewWithBaseJS(nil, `var network = "testnet";`)
+
+	var wg sync.WaitGroup
+	responses := make([]string, 2)
+	for i, jail := range []*Jail{jail1, jail2} {
+		wg.Add(1)
+		go func(i int, jail *Jail) {
+			defer wg.Done()
+			responses[i] = jail.Parse("cell1", `var _status_catalog = {network: network};`)
+		}(i, jail)
+	}
+	wg.Wait()
+
+	require.Equal(t, `{"result": {"network":"mainnet"}}`, responses[0])
+	require.Equal(t, `{"result": {"network":"testnet"}}`, responses[1])
+
+	require.NoError(t, jail1.RemoveCell("cell1"))
+	require.Equal(t, []string{"cell1"}, jail2.Cells())
+}
+
+func TestReset(t *testing.T) {
+	jail := New(nil)
+
+	require.Equal(t, `{"error":"cell 'cell1' not found"}`, jail.Reset("cell1", `var _status_catalog = {};`))
+
+	jail.CreateGroup("group1", CellConfig{FetchTimeout: time.Second})
+	response := jail.ParseInGroup("cell1", "group1", `var _status_catalog = {version: 1};`)
+	require.Equal(t, `{"result": {"version":1}}`, response)
+	require.NoError(t, jail.SetCellEnabled("cell1", false))
+
+	old, err := jail.cell("cell1")
+	require.NoError(t, err)
+
+	response = jail.Reset("cell1", `var _status_catalog = {version: 2};`)
+	require.Equal(t, `{"result": {"version":2}}`, response)
+
+	cell, err := jail.cell("cell1")
+	require.NoError(t, err)
+	require.NotEqual(t, old, cell)
+	require.Equal(t, "cell1", cell.id)
+	require.NotNil(t, cell.group())
+	require.False(t, cell.enabled())
+	require.Equal(t, old.createdAt, cell.createdAt)
+
+	// the old VM is stopped
+	select {
+	case <-old.loopStopped:
+	case <-time.After(time.Second):
+		t.Fatal("old cell was not stopped")
+	}
+
+	// a failed reset keeps the cell
+	response = jail.Reset("cell1", `throw new Error("broken")`)
+	require.Contains(t, response, "broken")
+	catalog, err := jail.Catalog("cell1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"version": float64(2)}, catalog)
+}
+
+func TestCallWithArgs(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			var params = JSON.parse(paramsStr);
+			return params.to.length * params.amount;
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	args := struct {
+		To     string `json:"to"`
+		Amount int    `json:"amount"`
+	}{"0x01", 5}
+	require.Equal(t, `{"result": 20}`, jail.CallWithArgs("cell1", `["commands", "send"]`, args))
+	require.Equal(t, `{"result": 3}`, jail.CallWithArgs("cell1", `["commands", "send"]`, map[string]interface{}{"to": "bob", "amount": 1}))
+
+	response = jail.CallWithArgs("cell1", `["commands", "send"]`, make(chan int))
+	require.Contains(t, response, "failed to marshal args")
+
+	require.Equal(t, `{"error":"cell 'cell2' not found"}`, jail.CallWithArgs("cell2", `["commands", "send"]`, args))
+}
+
+func TestSetWeb3JS(t *testing.T) {
+	jail := New(nil)
+
+	jail.SetWeb3JS(`
+		var require = function(name) {
+			if (name === "web3") {
+				return function Web3(provider) { this.version = "pinned"; };
+			}
+			if (name === "bignumber.js") {
+				return function BigNumber(val) {};
+			}
+			throw new Error("unknown module " + name);
+		};
+	`)
+	response := jail.Parse("cell1", `var _status_catalog = {version: web3.version};`)
+	require.Equal(t, `{"result": {"version":"pinned"}}`, response)
+
+	// the bundle must define Web3
+	jail.SetWeb3JS(`var require = function(name) { return undefined; };`)
+	response = jail.Parse("cell2", `var _status_catalog = {};`)
+	require.Contains(t, response, ErrInvalidWeb3JS.Error())
+
+	// the bundled web3.js is restored
+	jail.SetWeb3JS("")
+	response = jail.Parse("cell3", `var _status_catalog = {version: typeof web3.version.api};`)
+	require.Equal(t, `{"result": {"version":"string"}}`, response)
+}
+
+func TestSendRaw(t *testing.T) {
+	// a jail without a client can't send requests
+	_, err := New(nil).SendRaw(testBlockNumberRequest)
+	require.Equal(t, ErrNoRPCClient, err)
+
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "eth_blockNumber" {
+			return "0x10", nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	response, err := jail.SendRaw(testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"0x10"}`, response)
+
+	// batches are supported
+	response, err = jail.SendRaw(`[` + testBlockNumberRequest + `,{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber","params":[]}]`)
+	require.NoError(t, err)
+	require.Equal(t, `[{"id":1,"jsonrpc":"2.0","result":"0x10"},{"id":2,"jsonrpc":"2.0","result":"0x10"}]`, response)
+
+	// requests are processed like requests of cells
+	jail.SetBlockedMethods([]string{"eth_blockNumber"})
+	response, err = jail.SendRaw(testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Contains(t, response, `"code":-32601`)
+
+	// malformed requests get an error response
+	response, err = jail.SendRaw(`{"jsonrpc":`)
+	require.NoError(t, err)
+	require.Equal(t, `{"error":{"code":-32700,"message":"parse error"},"id":0,"jsonrpc":"2.0"}`, response)
+}
+
+func TestCellStats(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	// The node hangs until the request is cancelled.
+	started := make(chan struct{}, 1)
+	provider.rpcClient.RegisterHandler("eth_blockNumber", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	jail := New(provider)
+	defer jail.Stop()
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return web3.eth.blockNumber;
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	waitTotal, timeouts, err := jail.CellStats("cell1")
+	require.NoError(t, err)
+	require.Equal(t, 0, timeouts)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	done := make(chan string, 1)
+	go func() {
+		done <- jail.CallContext(ctx1, "cell1", `["commands", "blockNumber"]`, `{}`)
+	}()
+	<-started
+
+	// a call waiting for the call in progress times out
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	require.Equal(t, `{"error":"context deadline exceeded"}`, jail.CallContext(ctx2, "cell1", `["commands", "blockNumber"]`, `{}`))
+
+	cancel1()
+	require.Contains(t, <-done, "context canceled")
+
+	previousWait := waitTotal
+	waitTotal, timeouts, err = jail.CellStats("cell1")
+	require.NoError(t, err)
+	require.Equal(t, 1, timeouts)
+	require.True(t, waitTotal > previousWait, "wait time was not recorded")
+
+	_, _, err = jail.CellStats("cell2")
+	require.Error(t, err)
+}
+
+func TestCellWaitTimeout(t *testing.T) {
+	jail := New(nil)
+	defer jail.Stop()
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return 1;
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	cell, err := jail.cell("cell1")
+	require.NoError(t, err)
+
+	jail.SetCellWaitTimeout(50 * time.Millisecond)
+
+	// a call in progress holds the cell
+	cell.lockCalls()
+	require.Equal(t, `{"error":"timed out waiting for cell"}`, jail.Call("cell1", `["commands", "call"]`, `{}`))
+	cell.unlockCalls()
+
+	_, timeouts, err := jail.CellStats("cell1")
+	require.NoError(t, err)
+	require.Equal(t, 1, timeouts)
+
+	// the timed out call didn't take the cell
+	require.Equal(t, `{"result": 1}`, jail.Call("cell1", `["commands", "call"]`, `{}`))
+	require.Equal(t, `{"result": 1}`, jail.Call("cell1", `["commands", "call"]`, `{}`))
+}
+
+func TestEmptyBaseJSWarning(t *testing.T) {
+	var warnings []string
+	logWarn := func(msg string, ctx ...interface{}) {
+		warnings = append(warnings, msg)
+	}
+
+	jail := New(nil)
+	defer jail.Stop()
+	jail.logWarn = logWarn
+
+	// cells are parsed without base JS, but a warning is logged once
+	require.Equal(t, `{"result": {}}`, jail.Parse("cell1", `var _status_catalog = {};`))
+	require.Equal(t, `{"result": {}}`, jail.Parse("cell2", `var _status_catalog = {};`))
+	require.Equal(t, []string{"jail is not initialized with status JS, cells are parsed without it"}, warnings)
+
+	// no warning is logged if base JS is set
+	warnings = nil
+	jail = NewWithBaseJS(nil, `var base = true;`)
+	defer jail.Stop()
+	jail.logWarn = logWarn
+
+	require.Equal(t, `{"result": {}}`, jail.Parse("cell1", `var _status_catalog = {};`))
+	require.Empty(t, warnings)
+}
@@ -0,0 +1,95 @@
+package jail
+
+import (
+	"fmt"
+	"strings"
+)
+
+// methodNotAllowedErrorCode is a JSON-RPC error code of requests
+// calling methods which cells are not allowed to call.
+const methodNotAllowedErrorCode = -32601
+
+// SetAllowedMethods restricts RPC methods cells can call to the given ones.
+// A name ending with "*" matches methods with the preceding prefix,
+// e.g. "eth_*". Nil or an empty list allows all methods, which is the default.
+// The list is copied.
+func (j *Jail) SetAllowedMethods(methods []string) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.allowedMethods = append([]string(nil), methods...)
+}
+
+// SetBlockedMethods sets RPC methods cells are not allowed to call,
+// e.g. "personal_*" or "admin_*" methods, matched like in SetAllowedMethods.
+// It applies to all cells in addition to BlockedMethods of groups.
+// The block list takes precedence over the list set with SetAllowedMethods.
+// The list is copied.
+func (j *Jail) SetBlockedMethods(methods []string) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.blockedMethods = append([]string(nil), methods...)
+}
+
+// methodNotAllowedResponse returns a -32601 error response if any request
+// of a raw JSON-RPC payload of a cell calls a method which is not allowed
+// by the jail or blocked for the cell's group, see CellConfig.
+// A batch is rejected as a whole and none of its requests are executed.
+func (j *Jail) methodNotAllowedResponse(cell *Cell, request string) (interface{}, bool) {
+	j.settingsMx.RLock()
+	allowed := j.allowedMethods
+	blocked := j.blockedMethods
+	j.settingsMx.RUnlock()
+
+	if groupBlocked := j.cellConfig(cell).BlockedMethods; len(groupBlocked) > 0 {
+		blocked = append(append([]string(nil), blocked...), groupBlocked...)
+	}
+
+	if len(allowed) == 0 && len(blocked) == 0 {
+		return nil, false
+	}
+
+	requests, err := decodeRequests(request)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, req := range requests {
+		if methodAllowed(allowed, blocked, req.Method) {
+			continue
+		}
+
+		err := fmt.Errorf("%s: %s", ErrMethodNotAllowed, req.Method)
+		response, err := newErrorResponses(request, methodNotAllowedErrorCode, err)
+		return response, err == nil
+	}
+
+	return nil, false
+}
+
+// methodAllowed returns true if a method is not blocked and is either
+// in the allowed list or the allowed list is empty.
+func methodAllowed(allowed, blocked []string, method string) bool {
+	if methodListed(blocked, method) {
+		return false
+	}
+
+	return len(allowed) == 0 || methodListed(allowed, method)
+}
+
+// methodListed returns true if a method matches any name of a list.
+// A name ending with "*" matches methods with the preceding prefix.
+func methodListed(names []string, method string) bool {
+	for _, name := range names {
+		if strings.HasSuffix(name, "*") {
+			if strings.HasPrefix(method, strings.TrimSuffix(name, "*")) {
+				return true
+			}
+		} else if name == method {
+			return true
+		}
+	}
+
+	return false
+}
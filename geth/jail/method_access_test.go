@@ -0,0 +1,98 @@
+package jail
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAllowedAndBlockedMethods(t *testing.T) {
+	var requests int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&requests, 1)
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	const (
+		gasPriceRequest = `{"jsonrpc":"2.0","id":2,"method":"eth_gasPrice","params":[]}`
+		personalRequest = `{"jsonrpc":"2.0","id":3,"method":"personal_listAccounts","params":[]}`
+	)
+
+	// all methods are allowed by default
+	response, err := jail.sendRPCCall(nil, personalRequest)
+	require.NoError(t, err)
+	require.NotContains(t, response, "error")
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	jail.SetBlockedMethods([]string{"personal_listAccounts"})
+	response, err = jail.sendRPCCall(nil, personalRequest)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      float64(3),
+		"error": map[string]interface{}{
+			"code":    float64(-32601),
+			"message": "method not allowed: personal_listAccounts",
+		},
+	}, response)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// a blocked method rejects the whole batch
+	response, err = jail.sendRPCCall(nil, "["+testBlockNumberRequest+","+personalRequest+"]")
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	for _, r := range response.([]interface{}) {
+		require.Equal(t, float64(-32601), r.(map[string]interface{})["error"].(map[string]interface{})["code"])
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// only allowed methods can be called
+	jail.SetBlockedMethods(nil)
+	jail.SetAllowedMethods([]string{"eth_blockNumber"})
+	response, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.NotContains(t, response, "error")
+	response, err = jail.sendRPCCall(nil, gasPriceRequest)
+	require.NoError(t, err)
+	require.Contains(t, response, "error")
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// the block list takes precedence
+	jail.SetBlockedMethods([]string{"eth_blockNumber"})
+	response, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Contains(t, response, "error")
+
+	// names ending with "*" match prefixes
+	jail.SetAllowedMethods(nil)
+	methods := []string{"personal_*"}
+	jail.SetBlockedMethods(methods)
+	response, err = jail.sendRPCCall(nil, personalRequest)
+	require.NoError(t, err)
+	require.Contains(t, response, "error")
+	response, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.NotContains(t, response, "error")
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	// and the lists are copied
+	methods[0] = "eth_*"
+	response, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.NotContains(t, response, "error")
+	require.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	jail.SetBlockedMethods(nil)
+	response, err = jail.sendRPCCall(nil, "["+testBlockNumberRequest+","+gasPriceRequest+"]")
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.Equal(t, int32(6), atomic.LoadInt32(&requests))
+}
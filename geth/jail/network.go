@@ -0,0 +1,98 @@
+package jail
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// networkIDCache caches the selected network ID.
+type networkIDCache struct {
+	mx     sync.Mutex
+	id     uint64
+	known  bool
+	client *rpc.Client // client the ID was obtained with, nil if it was set
+}
+
+// NetworkID returns the ID of the selected network. Unless it was set
+// with SetNetworkID, it's obtained from the node with net_version
+// and cached until the RPC client changes, e.g. when the node is restarted
+// with another network. Caches of the previous network are discarded then.
+func (j *Jail) NetworkID() (uint64, error) {
+	cache := &j.network
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	if cache.known && cache.client == nil {
+		return cache.id, nil
+	}
+
+	client := j.RPCClient()
+	if client == nil {
+		return 0, ErrNoRPCClient
+	}
+
+	if cache.known && cache.client == client {
+		return cache.id, nil
+	}
+
+	var version string
+	if err := client.Call(&version, "net_version"); err != nil {
+		return 0, err
+	}
+
+	id, err := strconv.ParseUint(version, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid network ID: %s", version)
+	}
+
+	if cache.known && cache.id != id {
+		j.resetNetworkCaches()
+	}
+
+	cache.id = id
+	cache.known = true
+	cache.client = client
+
+	return id, nil
+}
+
+// SetNetworkID selects the network with the given ID. If it differs from
+// the current one, caches of the previous network are discarded and
+// the RPC client is invalidated, see InvalidateClient.
+func (j *Jail) SetNetworkID(id uint64) {
+	cache := &j.network
+	cache.mx.Lock()
+	changed := !cache.known || cache.id != id
+	cache.id = id
+	cache.known = true
+	cache.client = nil
+	cache.mx.Unlock()
+
+	if !changed {
+		return
+	}
+
+	j.resetNetworkCaches()
+	j.InvalidateClient()
+}
+
+// resetNetworkCaches discards cached data which belongs to a network.
+func (j *Jail) resetNetworkCaches() {
+	j.blockNumber.mx.Lock()
+	j.blockNumber.client = nil
+	j.blockNumber.number = 0
+	j.blockNumber.mx.Unlock()
+
+	j.responseCache.mx.Lock()
+	j.responseCache.block = 0
+	j.responseCache.results = make(map[string]cachedResult)
+	j.responseCache.mx.Unlock()
+
+	j.noncesMx.Lock()
+	j.nonces = make(map[gethcommon.Address]uint64)
+	j.noncesMx.Unlock()
+}
@@ -0,0 +1,106 @@
+package jail
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkID(t *testing.T) {
+	var versionRequests int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&versionRequests, 1)
+		return "3", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	for i := 0; i < 2; i++ {
+		id, err := jail.NetworkID()
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), id)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&versionRequests))
+	require.NotNil(t, jail.client)
+
+	// the same network keeps the client
+	jail.SetNetworkID(3)
+	require.NotNil(t, jail.client)
+
+	jail.noncesMx.Lock()
+	jail.nonces[gethcommon.HexToAddress("0xadaf150b905cf5e6a778e553e15a139b6618bbb7")] = 5
+	jail.noncesMx.Unlock()
+
+	// a new network invalidates the client and caches
+	jail.SetNetworkID(4)
+	require.Nil(t, jail.client)
+	require.Empty(t, jail.nonces)
+
+	id, err := jail.NetworkID()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), id)
+	require.Equal(t, int32(1), atomic.LoadInt32(&versionRequests))
+
+	_, err = New(nil).NetworkID()
+	require.Equal(t, ErrNoRPCClient, err)
+}
+
+func TestNetworkIDFollowsClient(t *testing.T) {
+	newServer := func(version, blockNumber string) *httptest.Server {
+		return newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+			if method == "net_version" {
+				return version, nil
+			}
+			return blockNumber, nil
+		})
+	}
+
+	ts1 := newServer("1", "0x1")
+	defer ts1.Close()
+	ts2 := newServer("3", "0x2")
+	defer ts2.Close()
+
+	provider1, err := newTestRPCClientProvider(ts1.URL)
+	require.NoError(t, err)
+	provider2, err := newTestRPCClientProvider(ts2.URL)
+	require.NoError(t, err)
+
+	provider := &testRPCClientProvider{provider1.rpcClient}
+	jail := New(provider)
+
+	id, err := jail.NetworkID()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+
+	address := gethcommon.HexToAddress("0xadaf150b905cf5e6a778e553e15a139b6618bbb7")
+	jail.noncesMx.Lock()
+	jail.nonces[address] = 5
+	jail.noncesMx.Unlock()
+
+	// the node is restarted with another network
+	provider.rpcClient = provider2.rpcClient
+
+	response, err := jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, "0x2", response.(map[string]interface{})["result"])
+
+	id, err = jail.NetworkID()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), id)
+	require.Empty(t, jail.nonces)
+
+	// a network ID which was set is kept
+	jail.SetNetworkID(4)
+	provider.rpcClient = provider1.rpcClient
+	id, err = jail.NetworkID()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), id)
+}
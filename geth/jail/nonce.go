@@ -0,0 +1,258 @@
+package jail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+const sendTransactionMethod = "eth_sendTransaction"
+
+// EnableNonceManagement enables or disables nonce management.
+// When enabled, eth_sendTransaction requests without a nonce get
+// the next nonce of the sender from the jail's cache, so that
+// multiple transactions can be sent without waiting for the
+// node to update its pending state.
+func (j *Jail) EnableNonceManagement(enabled bool) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.nonceManagement = enabled
+}
+
+func (j *Jail) nonceManagementEnabled() bool {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	return j.nonceManagement
+}
+
+// ResyncNonce fetches the pending nonce of an address from the node
+// and overwrites the cached one. It should be called when transactions
+// are sent from the address outside of the jail.
+func (j *Jail) ResyncNonce(address string) error {
+	if !gethcommon.IsHexAddress(address) {
+		return fmt.Errorf("invalid address: %s", address)
+	}
+
+	client := j.RPCClient()
+	if client == nil {
+		return ErrNoRPCClient
+	}
+
+	return j.resyncNonce(client, gethcommon.HexToAddress(address))
+}
+
+// resyncNonce fetches the pending nonce of an address and overwrites
+// the cached one. The node is queried without holding noncesMx, so that
+// reservations of other addresses aren't blocked by the round trip.
+func (j *Jail) resyncNonce(client *rpc.Client, address gethcommon.Address) error {
+	nonce, err := pendingNonce(client, address)
+	if err != nil {
+		return err
+	}
+
+	j.noncesMx.Lock()
+	j.nonces[address] = nonce
+	j.noncesMx.Unlock()
+
+	return nil
+}
+
+// reserveNonce returns the next nonce of an address and increments the cached one.
+// If the nonce is not cached, it's fetched from the node without holding noncesMx.
+func (j *Jail) reserveNonce(client *rpc.Client, address gethcommon.Address) (uint64, error) {
+	j.noncesMx.Lock()
+	nonce, ok := j.nonces[address]
+	if ok {
+		j.nonces[address] = nonce + 1
+	}
+	j.noncesMx.Unlock()
+
+	if ok {
+		return nonce, nil
+	}
+
+	fetched, err := pendingNonce(client, address)
+	if err != nil {
+		return 0, err
+	}
+
+	j.noncesMx.Lock()
+	defer j.noncesMx.Unlock()
+
+	// Another request may have cached the nonce in the meantime.
+	if nonce, ok = j.nonces[address]; !ok {
+		nonce = fetched
+	}
+	j.nonces[address] = nonce + 1
+
+	return nonce, nil
+}
+
+// releaseNonce returns a reserved nonce to the cache if it's the latest
+// reservation of an address, so that it's reused by the next transaction.
+func (j *Jail) releaseNonce(reservation nonceReservation) {
+	j.noncesMx.Lock()
+	defer j.noncesMx.Unlock()
+
+	if j.nonces[reservation.address] == reservation.nonce+1 {
+		j.nonces[reservation.address] = reservation.nonce
+	}
+}
+
+// nonceReservation is a nonce reserved for a request.
+type nonceReservation struct {
+	id      string // compacted ID of the request
+	address gethcommon.Address
+	nonce   uint64
+}
+
+// injectNonces sets nonces in eth_sendTransaction requests which don't have it.
+// It returns the modified request and the reserved nonces.
+func (j *Jail) injectNonces(client *rpc.Client, request string) (string, []nonceReservation, error) {
+	// Malformed requests are handled by the RPC client.
+	if _, err := decodeRequests(request); err != nil {
+		return request, nil, nil
+	}
+
+	var reservations []nonceReservation
+	request, err := rewriteRequests(request, func(msg map[string]json.RawMessage) error {
+		if requestMethod(msg) != sendTransactionMethod {
+			return nil
+		}
+
+		var params []json.RawMessage
+		if err := json.Unmarshal(msg["params"], &params); err != nil || len(params) == 0 {
+			return nil
+		}
+
+		var tx map[string]json.RawMessage
+		if err := json.Unmarshal(params[0], &tx); err != nil || tx == nil {
+			return nil
+		}
+
+		if value, ok := tx["nonce"]; ok && string(value) != "null" {
+			return nil
+		}
+
+		var from string
+		if err := json.Unmarshal(tx["from"], &from); err != nil || !gethcommon.IsHexAddress(from) {
+			return nil
+		}
+		address := gethcommon.HexToAddress(from)
+
+		nonce, err := j.reserveNonce(client, address)
+		if err != nil {
+			return err
+		}
+		reservations = append(reservations, nonceReservation{id: compactID(msg["id"]), address: address, nonce: nonce})
+
+		if tx["nonce"], err = json.Marshal(hexutil.Uint64(nonce)); err != nil {
+			return err
+		}
+
+		if params[0], err = json.Marshal(tx); err != nil {
+			return err
+		}
+
+		msg["params"], err = json.Marshal(params)
+		return err
+	})
+
+	return request, reservations, err
+}
+
+// resyncFailedNonces updates nonces reserved for requests whose responses
+// are errors. A nonce-related error, like "nonce too low", means that the
+// cache drifted from the node, so the nonce is resynced. Otherwise, the
+// transaction was rejected and its nonce is released to be reused.
+func (j *Jail) resyncFailedNonces(client *rpc.Client, reservations []nonceReservation, rawResponse string) {
+	if len(reservations) == 0 {
+		return
+	}
+
+	errs, ok := responseErrors(rawResponse)
+	for _, reservation := range reservations {
+		message, failed := errs[reservation.id]
+		switch {
+		case ok && !failed:
+			continue
+		case ok && !isNonceError(message):
+			j.releaseNonce(reservation)
+			continue
+		}
+
+		// The nonce is resynced if the response can't be decoded.
+		if err := j.resyncNonce(client, reservation.address); err != nil {
+			log.Warn("failed to resync nonce", "address", reservation.address.Hex(), "err", err)
+		}
+	}
+}
+
+// isNonceError returns true if an error message reported by the node
+// means that the nonce of a transaction is out of sync.
+func isNonceError(message string) bool {
+	return strings.Contains(strings.ToLower(message), "nonce")
+}
+
+// responseErrors returns error messages of a raw JSON-RPC response
+// or of responses of a batch, keyed by compacted IDs of the responses.
+// It returns false if the response can't be decoded.
+func responseErrors(rawResponse string) (map[string]string, bool) {
+	var responses []rpcResponse
+
+	if isBatchRequest(rawResponse) {
+		if err := json.Unmarshal([]byte(rawResponse), &responses); err != nil {
+			return nil, false
+		}
+	} else {
+		var response rpcResponse
+		if err := json.Unmarshal([]byte(rawResponse), &response); err != nil {
+			return nil, false
+		}
+		responses = append(responses, response)
+	}
+
+	errs := make(map[string]string)
+	for _, response := range responses {
+		if len(response.Error) == 0 || string(response.Error) == "null" {
+			continue
+		}
+
+		var rpcErr jsonrpcError
+		if err := json.Unmarshal(response.Error, &rpcErr); err != nil {
+			return nil, false
+		}
+		errs[compactID(response.ID)] = rpcErr.Message
+	}
+
+	return errs, true
+}
+
+// compactID returns a JSON-RPC ID without insignificant whitespace,
+// so that IDs of requests and responses can be compared.
+func compactID(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil {
+		return string(id)
+	}
+
+	return buf.String()
+}
+
+// pendingNonce returns the pending nonce of an address.
+func pendingNonce(client *rpc.Client, address gethcommon.Address) (uint64, error) {
+	var nonce hexutil.Uint64
+	if err := client.Call(&nonce, "eth_getTransactionCount", address, "pending"); err != nil {
+		return 0, err
+	}
+
+	return uint64(nonce), nil
+}
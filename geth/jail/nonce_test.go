@@ -0,0 +1,117 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonceManagement(t *testing.T) {
+	var (
+		mx           sync.Mutex
+		pendingCount uint64 = 5
+		sendErr      error
+		nonceQueries int
+	)
+	noncec := make(chan uint64, 1)
+
+	// queries of the other address block until unblocked
+	otherAddress := "0x65c01586aa0ce152c2701b2ad1162b8d859a7234"
+	queried := make(chan struct{})
+	unblock := make(chan struct{})
+
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "eth_getTransactionCount" && string(params[0]) == `"`+otherAddress+`"` {
+			close(queried)
+			<-unblock
+			return hexutil.Uint64(0), nil
+		}
+
+		mx.Lock()
+		defer mx.Unlock()
+
+		switch method {
+		case "eth_getTransactionCount":
+			nonceQueries++
+			return hexutil.Uint64(pendingCount), nil
+		case "eth_sendTransaction":
+			var tx struct {
+				Nonce hexutil.Uint64 `json:"nonce"`
+			}
+			require.NoError(t, json.Unmarshal(params[0], &tx))
+			noncec <- uint64(tx.Nonce)
+			if sendErr != nil {
+				return nil, sendErr
+			}
+			pendingCount++
+			return "0x", nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.EnableNonceManagement(true)
+
+	address := "0xadaf150b905cf5e6a778e553e15a139b6618bbb7"
+	sendTx := func() uint64 {
+		_, err := jail.sendRPCCall(nil, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[{"from":"`+address+`"}]}`)
+		require.NoError(t, err)
+		return <-noncec
+	}
+
+	require.Equal(t, uint64(5), sendTx())
+	require.Equal(t, uint64(6), sendTx())
+
+	// simulate transactions sent from another wallet
+	mx.Lock()
+	pendingCount = 10
+	mx.Unlock()
+
+	require.NoError(t, jail.ResyncNonce(address))
+	require.Equal(t, uint64(10), sendTx())
+
+	// "nonce too low" error triggers resync
+	mx.Lock()
+	pendingCount = 20
+	sendErr = errors.New("nonce too low")
+	mx.Unlock()
+	require.Equal(t, uint64(11), sendTx())
+
+	mx.Lock()
+	sendErr = nil
+	mx.Unlock()
+	require.Equal(t, uint64(20), sendTx())
+
+	// other errors release the nonce without querying the node
+	mx.Lock()
+	sendErr = errors.New("insufficient funds for gas * price + value")
+	queries := nonceQueries
+	mx.Unlock()
+	require.Equal(t, uint64(21), sendTx())
+
+	mx.Lock()
+	sendErr = nil
+	require.Equal(t, queries, nonceQueries)
+	mx.Unlock()
+	require.Equal(t, uint64(21), sendTx())
+
+	// nonces are reserved while the node is queried for another address
+	errc := make(chan error, 1)
+	go func() {
+		errc <- jail.ResyncNonce(otherAddress)
+	}()
+	<-queried
+	require.Equal(t, uint64(22), sendTx())
+	close(unblock)
+	require.NoError(t, <-errc)
+
+	require.EqualError(t, jail.ResyncNonce("0xinvalid"), "invalid address: 0xinvalid")
+}
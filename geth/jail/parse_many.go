@@ -0,0 +1,38 @@
+package jail
+
+import (
+	"context"
+	"sort"
+)
+
+// ParseMany parses multiple scripts, keyed by chat IDs, like Parse.
+// It returns Parse responses keyed by chat IDs.
+func (j *Jail) ParseMany(scripts map[string]string) map[string]string {
+	results, _ := j.ParseManyContext(context.Background(), scripts) //nolint: errcheck
+	return results
+}
+
+// ParseManyContext works like ParseMany, but it stops parsing scripts
+// when ctx is cancelled. In that case, responses of the scripts parsed
+// so far are returned with ctx.Err(). Scripts are parsed in order
+// of their chat IDs.
+func (j *Jail) ParseManyContext(ctx context.Context, scripts map[string]string) (map[string]string, error) {
+	chatIDs := make([]string, 0, len(scripts))
+	for chatID := range scripts {
+		chatIDs = append(chatIDs, chatID)
+	}
+	sort.Strings(chatIDs)
+
+	results := make(map[string]string, len(scripts))
+	for _, chatID := range chatIDs {
+		select {
+		case <-ctx.Done():
+			return results, ctx.Err()
+		default:
+		}
+
+		results[chatID] = j.Parse(chatID, scripts[chatID])
+	}
+
+	return results, nil
+}
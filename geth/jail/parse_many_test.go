@@ -0,0 +1,48 @@
+package jail
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManyContext(t *testing.T) {
+	jail := New(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the second script cancels parsing
+	jail.SetRequireResolver(func(moduleName string) (string, bool) {
+		if moduleName != "cancel" {
+			return "", false
+		}
+		cancel()
+		return `module.exports = {};`, true
+	})
+
+	const script = `var _status_catalog = {};`
+	results, err := jail.ParseManyContext(ctx, map[string]string{
+		"cell1": script,
+		"cell2": `require("cancel");` + script,
+		"cell3": script,
+		"cell4": script,
+	})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, map[string]string{
+		"cell1": `{"result": {}}`,
+		"cell2": `{"result": {}}`,
+	}, results)
+
+	_, err = jail.Cell("cell2")
+	require.NoError(t, err)
+	_, err = jail.Cell("cell3")
+	require.EqualError(t, err, "cell 'cell3' not found")
+
+	// the remaining scripts are parsed without cancellation
+	results = jail.ParseMany(map[string]string{"cell3": script, "cell4": script})
+	require.Len(t, results, 2)
+	_, err = jail.Cell("cell4")
+	require.NoError(t, err)
+}
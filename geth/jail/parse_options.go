@@ -0,0 +1,65 @@
+package jail
+
+import (
+	"strings"
+	"time"
+
+	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+)
+
+// ErrScriptTimeout is returned when code exceeds ParseOptions.Timeout.
+var ErrScriptTimeout = vm.ErrTimeout
+
+// ParseOptions configures how ParseWithOptions sets up a cell.
+type ParseOptions struct {
+	// JS is the code of the cell, which must set _status_catalog.
+	JS string
+	// IncludeConsole forwards console messages to the log.
+	// Otherwise, they are discarded.
+	IncludeConsole bool
+	// Bundles are names of bundles registered with RegisterBundle,
+	// which are run in the given order before JS.
+	Bundles []string
+	// Timeout interrupts the code if it runs longer, e.g. because
+	// of an infinite loop. Zero means no limit.
+	Timeout time.Duration
+}
+
+// defaultParseOptions returns options Parse uses for the code.
+func defaultParseOptions(code string) ParseOptions {
+	return ParseOptions{JS: code, IncludeConsole: true}
+}
+
+// ParseWithOptions works like Parse, but the cell is set up according
+// to the options. Parse is equivalent to ParseWithOptions with JS set
+// to the code and IncludeConsole set to true.
+func (j *Jail) ParseWithOptions(chatID string, opts ParseOptions) string {
+	// Reject unknown bundles before a cell is created.
+	if _, err := j.bundleScripts(opts.Bundles); err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	return j.parse(chatID, opts, nil)
+}
+
+// runScript runs the bundles and the code of a cell according to the options.
+func (j *Jail) runScript(cell *Cell, opts ParseOptions) (otto.Value, error) {
+	code := opts.JS
+	if len(opts.Bundles) > 0 {
+		scripts, err := j.bundleScripts(opts.Bundles)
+		if err != nil {
+			return otto.UndefinedValue(), err
+		}
+
+		code = strings.Join(append(scripts, opts.JS), ";\n")
+	}
+
+	if !opts.IncludeConsole {
+		if err := registerSilentConsole(cell); err != nil {
+			return otto.UndefinedValue(), err
+		}
+	}
+
+	return cell.RunTimeout(code, opts.Timeout)
+}
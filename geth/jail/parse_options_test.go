@@ -0,0 +1,54 @@
+package jail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWithOptions(t *testing.T) {
+	var messages []string
+	original := consoleLoggers
+	consoleLoggers = map[string]func(msg string, ctx ...interface{}){
+		"log": func(msg string, ctx ...interface{}) {
+			messages = append(messages, msg)
+		},
+	}
+	defer func() { consoleLoggers = original }()
+
+	jail := New(nil)
+	defer jail.Stop()
+
+	jail.RegisterBundle("tokens", `var tokens = ["SNT"];`)
+
+	// Parse includes the console
+	response := jail.Parse("cell1", `console.log("parsed"); var _status_catalog = {};`)
+	require.Equal(t, `{"result": {}}`, response)
+	require.Equal(t, []string{"[cell1] parsed"}, messages)
+
+	// which can be left out
+	response = jail.ParseWithOptions("cell2", ParseOptions{
+		JS:      `console.log("silent"); var _status_catalog = {tokens: tokens};`,
+		Bundles: []string{"tokens"},
+	})
+	require.Equal(t, `{"result": {"tokens":["SNT"]}}`, response)
+	require.Len(t, messages, 1)
+
+	// long-running code is interrupted
+	start := time.Now()
+	response = jail.ParseWithOptions("cell3", ParseOptions{
+		JS:      `while (true) {}`,
+		Timeout: 50 * time.Millisecond,
+	})
+	require.Equal(t, `{"error":"`+ErrScriptTimeout.Error()+`"}`, response)
+	require.True(t, time.Since(start) < 5*time.Second)
+
+	// the cell can be used after the timeout
+	response = jail.ParseWithOptions("cell3", ParseOptions{
+		JS:      `var _status_catalog = {};`,
+		Timeout: 50 * time.Millisecond,
+	})
+	require.Equal(t, `{"result": {}}`, response)
+	require.Equal(t, "2", jail.Execute("cell3", `1 + 1`))
+}
@@ -0,0 +1,136 @@
+package jail
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// responseCacheFile is a name of a file in the persistence directory
+// the response cache is saved to.
+const responseCacheFile = "response_cache.json"
+
+// persistedResponseCache is the on-disk form of the response cache.
+type persistedResponseCache struct {
+	NetworkID uint64                  `json:"networkId"`
+	Block     uint64                  `json:"block"`
+	Results   []persistedCachedResult `json:"results"`
+}
+
+// persistedCachedResult is the on-disk form of a cached result.
+type persistedCachedResult struct {
+	Key    string          `json:"key"`
+	Block  uint64          `json:"block"`
+	Latest bool            `json:"latest"`
+	Result json.RawMessage `json:"result"`
+}
+
+// SetPersistenceDir sets a directory the jail's caches are saved to
+// on Shutdown and restored from on WarmUp. An empty dir disables persistence.
+func (j *Jail) SetPersistenceDir(dir string) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.persistenceDir = dir
+}
+
+func (j *Jail) getPersistenceDir() string {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	return j.persistenceDir
+}
+
+// Shutdown stops the jail like Stop and saves the response cache
+// to the persistence directory, if it's set. The cache is saved
+// along with the network ID, so nothing is saved if it's unknown.
+func (j *Jail) Shutdown() error {
+	j.Stop()
+
+	dir := j.getPersistenceDir()
+	if dir == "" {
+		return nil
+	}
+
+	networkID, err := j.NetworkID()
+	if err != nil {
+		return err
+	}
+
+	// Results at the "latest" block are dropped as soon as
+	// the block number advances, so it's safe to keep them.
+	cache := &j.responseCache
+	cache.mx.Lock()
+	persisted := persistedResponseCache{NetworkID: networkID, Block: cache.block}
+	for key, cached := range cache.results {
+		// Results of methods are bound to the RPC client.
+		if cached.timed {
+			continue
+		}
+		persisted.Results = append(persisted.Results, persistedCachedResult{
+			Key:    key,
+			Block:  cached.block,
+			Latest: cached.latest,
+			Result: cached.result,
+		})
+	}
+	cache.mx.Unlock()
+
+	data, err := json.Marshal(persisted)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, responseCacheFile), data, 0600)
+}
+
+// WarmUp restores the response cache saved by Shutdown from the persistence
+// directory. It should be called after EnableResponseCache, which resets the cache.
+// Missing files are ignored. A cache saved on another network is deleted.
+func (j *Jail) WarmUp() error {
+	dir := j.getPersistenceDir()
+	if dir == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, responseCacheFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var persisted persistedResponseCache
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return err
+	}
+
+	networkID, err := j.NetworkID()
+	if err != nil {
+		return err
+	}
+	if persisted.NetworkID != networkID {
+		return os.Remove(filepath.Join(dir, responseCacheFile))
+	}
+
+	cache := &j.responseCache
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	if cache.results == nil {
+		cache.results = make(map[string]cachedResult)
+	}
+	if persisted.Block > cache.block {
+		cache.block = persisted.Block
+	}
+	for _, result := range persisted.Results {
+		cache.results[result.Key] = cachedResult{block: result.Block, latest: result.Latest, result: result.Result}
+	}
+
+	return nil
+}
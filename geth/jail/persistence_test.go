@@ -0,0 +1,77 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdownPersistsResponseCache(t *testing.T) {
+	var (
+		calls   int32
+		network atomic.Value
+	)
+	network.Store("1")
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "net_version":
+			return network.Load(), nil
+		case "eth_getBalance":
+			atomic.AddInt32(&calls, 1)
+			return "0x2a", nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir(os.TempDir(), "jail_persistence")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	const request = `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x65c01586aa0ce152c2701b2ad1162b8d859a7234","0x10"]}`
+
+	jail := New(provider)
+	jail.EnableResponseCache(true)
+	jail.SetPersistenceDir(dir)
+	// nothing to restore yet
+	require.NoError(t, jail.WarmUp())
+
+	_, err = jail.sendRPCCall(nil, request)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	require.NoError(t, jail.Shutdown())
+
+	jail = New(provider)
+	jail.EnableResponseCache(true)
+	jail.SetPersistenceDir(dir)
+	require.NoError(t, jail.WarmUp())
+
+	response, err := jail.sendRPCCall(nil, request)
+	require.NoError(t, err)
+	require.Equal(t, "0x2a", response.(map[string]interface{})["result"])
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	require.NoError(t, jail.Shutdown())
+
+	// the cache is dropped if the node is on another network
+	network.Store("3")
+	jail = New(provider)
+	jail.EnableResponseCache(true)
+	jail.SetPersistenceDir(dir)
+	require.NoError(t, jail.WarmUp())
+
+	_, err = jail.sendRPCCall(nil, request)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	_, err = os.Stat(filepath.Join(dir, responseCacheFile))
+	require.True(t, os.IsNotExist(err))
+}
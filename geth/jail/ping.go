@@ -0,0 +1,27 @@
+package jail
+
+import (
+	"context"
+	"time"
+)
+
+// pingTimeout is a timeout of the request sent by Ping.
+var pingTimeout = 5 * time.Second
+
+// Ping checks if the node is reachable by sending a lightweight
+// net_version request. It returns nil if the node responded, or
+// ErrNoRPCClient or an error of the request otherwise.
+// No cell is needed, so hosts can check whether the jail is usable
+// before showing DApps.
+func (j *Jail) Ping() error {
+	client := j.RPCClient()
+	if client == nil {
+		return ErrNoRPCClient
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	var version string
+	return client.CallContext(ctx, &version, "net_version")
+}
@@ -0,0 +1,38 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	// a jail without a client is not usable
+	require.Equal(t, ErrNoRPCClient, New(nil).Ping())
+
+	var failing int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		require.Equal(t, "net_version", method)
+		if atomic.LoadInt32(&failing) == 1 {
+			return nil, errors.New("node is syncing")
+		}
+		return "1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	require.NoError(t, jail.Ping())
+
+	atomic.StoreInt32(&failing, 1)
+	require.EqualError(t, jail.Ping(), "node is syncing")
+
+	// as well as an unreachable node
+	ts.Close()
+	require.Error(t, jail.Ping())
+}
@@ -0,0 +1,151 @@
+package jail
+
+import (
+	"sync"
+
+	"github.com/status-im/status-go/geth/log"
+)
+
+// cellPool keeps cells with web3.js already set up, so that
+// the expensive initialization is done before cells are created.
+type cellPool struct {
+	mx      sync.Mutex
+	size    int
+	cells   []*Cell
+	version int  // incremented when pooled cells become stale
+	filling bool // true if the pool is being filled in the background
+}
+
+// SetPoolSize sets a number of cells initialized in advance in the background.
+// Parse and CreateAndInitCell take cells from the pool and only run
+// the code of a chat, which makes them considerably faster.
+// Zero disables the pool, which is the default. Stop disables it as well.
+func (j *Jail) SetPoolSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+
+	j.pool.mx.Lock()
+	j.pool.size = size
+	var excess []*Cell
+	if len(j.pool.cells) > size {
+		excess = j.pool.cells[size:]
+		j.pool.cells = j.pool.cells[:size:size]
+	}
+	j.pool.mx.Unlock()
+
+	for _, cell := range excess {
+		cell.Stop() //nolint: errcheck
+	}
+
+	j.fillPool()
+}
+
+// pooledCell takes a cell from the pool and assigns it an ID.
+// It returns nil if the pool is empty.
+func (j *Jail) pooledCell(chatID string) *Cell {
+	j.pool.mx.Lock()
+	var cell *Cell
+	if n := len(j.pool.cells); n > 0 {
+		cell = j.pool.cells[n-1]
+		j.pool.cells[n-1] = nil
+		j.pool.cells = j.pool.cells[:n-1]
+	}
+	j.pool.mx.Unlock()
+
+	j.fillPool()
+
+	if cell != nil {
+		cell.id = chatID
+	}
+
+	return cell
+}
+
+// newInitializedCell returns an initialized cell, taken from the pool if possible.
+func (j *Jail) newInitializedCell(chatID string) (*Cell, error) {
+	if cell := j.pooledCell(chatID); cell != nil {
+		return cell, nil
+	}
+
+	cell, err := NewCell(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.initCell(cell); err != nil {
+		cell.Stop() //nolint: errcheck
+		return nil, err
+	}
+
+	return cell, nil
+}
+
+// drainPool replaces pooled cells, e.g. when they're initialized with stale base JS.
+func (j *Jail) drainPool() {
+	j.pool.mx.Lock()
+	cells := j.pool.cells
+	j.pool.cells = nil
+	j.pool.version++
+	j.pool.mx.Unlock()
+
+	for _, cell := range cells {
+		cell.Stop() //nolint: errcheck
+	}
+
+	j.fillPool()
+}
+
+// fillPool starts filling the pool in the background unless it's full.
+func (j *Jail) fillPool() {
+	j.pool.mx.Lock()
+	defer j.pool.mx.Unlock()
+
+	if j.pool.filling || len(j.pool.cells) >= j.pool.size {
+		return
+	}
+	j.pool.filling = true
+
+	go j.runPoolFilling()
+}
+
+// runPoolFilling initializes cells until the pool is full.
+func (j *Jail) runPoolFilling() {
+	for {
+		j.pool.mx.Lock()
+		if len(j.pool.cells) >= j.pool.size {
+			j.pool.filling = false
+			j.pool.mx.Unlock()
+			return
+		}
+		version := j.pool.version
+		j.pool.mx.Unlock()
+
+		cell, err := NewCell("")
+		if err == nil {
+			if err = j.initCell(cell); err != nil {
+				cell.Stop() //nolint: errcheck
+			}
+		}
+		if err != nil {
+			log.Warn("failed to initialize a pooled cell", "err", err)
+
+			j.pool.mx.Lock()
+			j.pool.filling = false
+			j.pool.mx.Unlock()
+			return
+		}
+
+		j.pool.mx.Lock()
+		if version == j.pool.version && len(j.pool.cells) < j.pool.size {
+			j.pool.cells = append(j.pool.cells, cell)
+			cell = nil
+		}
+		j.pool.mx.Unlock()
+
+		// The pool was drained or shrunk in the meantime.
+		if cell != nil {
+			cell.Stop() //nolint: errcheck
+		}
+	}
+}
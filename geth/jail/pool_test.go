@@ -0,0 +1,85 @@
+package jail
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// pooledCells returns a number of cells in the pool.
+func (j *Jail) pooledCells() int {
+	j.pool.mx.Lock()
+	defer j.pool.mx.Unlock()
+
+	return len(j.pool.cells)
+}
+
+// waitForPool waits until the pool has n cells.
+func waitForPool(t testing.TB, j *Jail, n int) {
+	deadline := time.Now().Add(10 * time.Second)
+	for j.pooledCells() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool has %d cells, expected %d", j.pooledCells(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCellPool(t *testing.T) {
+	jail := New(nil)
+	defer jail.Stop()
+
+	jail.SetPoolSize(2)
+	waitForPool(t, jail, 2)
+
+	// a pooled cell is taken and the pool is refilled
+	response := jail.Parse("cell1", `var _status_catalog = {id: statusSignals ? "ok" : ""};`)
+	require.Equal(t, `{"result": {"id":"ok"}}`, response)
+	waitForPool(t, jail, 2)
+
+	cell, err := jail.cell("cell1")
+	require.NoError(t, err)
+	require.Equal(t, "cell1", cell.id)
+
+	// pooled cells don't share state
+	require.Equal(t, "1", jail.Execute("cell1", `var shared = 1; shared`))
+	response = jail.Parse("cell2", `var _status_catalog = {shared: typeof shared};`)
+	require.Equal(t, `{"result": {"shared":"undefined"}}`, response)
+	require.Equal(t, "function", jail.Execute("cell2", `typeof bn`))
+
+	// cells are initialized with the current base JS
+	jail.SetBaseJS(`var base = "new";`)
+	waitForPool(t, jail, 2)
+	response = jail.Parse("cell3", `var _status_catalog = {base: base};`)
+	require.Equal(t, `{"result": {"base":"new"}}`, response)
+
+	// shrinking the pool stops excess cells
+	jail.SetPoolSize(1)
+	waitForPool(t, jail, 1)
+
+	jail.Stop()
+	require.Equal(t, 0, jail.pooledCells())
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, poolSize := range []int{0, 4} {
+		b.Run(fmt.Sprintf("pool=%d", poolSize), func(b *testing.B) {
+			jail := New(nil)
+			defer jail.Stop()
+
+			jail.SetPoolSize(poolSize)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Measure Parse only, not waiting for the pool to be refilled.
+				b.StopTimer()
+				waitForPool(b, jail, poolSize)
+				b.StartTimer()
+
+				jail.Parse(fmt.Sprintf("cell%d", i), `var _status_catalog = {};`)
+			}
+		})
+	}
+}
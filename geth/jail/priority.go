@@ -0,0 +1,148 @@
+package jail
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// SetMaxConcurrentRPC limits the number of RPC requests sent by cells
+// concurrently. When the limit is reached, requests wait for a free slot
+// and high-priority requests are serviced ahead of low-priority ones.
+// A slot is held only while a request is sent to the node.
+// Zero means no limit, which is the default.
+func (j *Jail) SetMaxConcurrentRPC(limit int) {
+	j.rpcScheduler.setLimit(limit)
+}
+
+// CallPriority works like Call, but RPC requests sent by the called
+// command are high-priority if high is true. High-priority requests
+// are serviced ahead of low-priority ones, like background polling,
+// when the RPC concurrency limit is reached.
+// See SetMaxConcurrentRPC.
+func (j *Jail) CallPriority(chatID, commandPath, args string, high bool) string {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	value, err := j.callCell(cell, commandPath, args, callOptions{high: high})
+	if err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	return newJailResultResponse(value.String())
+}
+
+// highPriority returns true if the cell is executing a high-priority call.
+func (c *Cell) highPriority() bool {
+	return atomic.LoadInt32(&c.priority) == 1
+}
+
+// setHighPriority marks the cell as executing a high-priority call.
+func (c *Cell) setHighPriority(high bool) {
+	var value int32
+	if high {
+		value = 1
+	}
+	atomic.StoreInt32(&c.priority, value)
+}
+
+// rpcScheduler limits the number of concurrent RPC requests
+// and grants free slots to high-priority requests first.
+type rpcScheduler struct {
+	mx     sync.Mutex
+	limit  int
+	active int
+	high   []chan struct{}
+	low    []chan struct{}
+}
+
+func (s *rpcScheduler) setLimit(limit int) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.limit = limit
+	s.dispatch()
+}
+
+// acquire blocks until a slot is available or ctx is cancelled.
+func (s *rpcScheduler) acquire(ctx context.Context, high bool) error {
+	s.mx.Lock()
+	if s.limit <= 0 || s.active < s.limit {
+		s.active++
+		s.mx.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	if high {
+		s.high = append(s.high, ready)
+	} else {
+		s.low = append(s.low, ready)
+	}
+	s.mx.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if !removeWaiter(&s.high, ready) && !removeWaiter(&s.low, ready) {
+		// The slot was granted in the meantime, so pass it on.
+		s.active--
+		s.dispatch()
+	}
+
+	return ctx.Err()
+}
+
+// removeWaiter removes a waiting request from a queue.
+// It returns false if the request isn't in the queue.
+func removeWaiter(queue *[]chan struct{}, ready chan struct{}) bool {
+	for i, r := range *queue {
+		if r == ready {
+			*queue = append((*queue)[:i], (*queue)[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// release frees a slot taken with acquire.
+func (s *rpcScheduler) release() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.active--
+	s.dispatch()
+}
+
+// dispatch grants free slots to waiting requests, high-priority first.
+// It must be called with mx held.
+func (s *rpcScheduler) dispatch() {
+	for len(s.high)+len(s.low) > 0 && (s.limit <= 0 || s.active < s.limit) {
+		var ready chan struct{}
+		if len(s.high) > 0 {
+			ready, s.high = s.high[0], s.high[1:]
+		} else {
+			ready, s.low = s.low[0], s.low[1:]
+		}
+
+		s.active++
+		close(ready)
+	}
+}
+
+// waiting returns the number of requests waiting for a slot.
+func (s *rpcScheduler) waiting() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return len(s.high) + len(s.low)
+}
@@ -0,0 +1,75 @@
+package jail
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallPriority(t *testing.T) {
+	served := make(chan string, 10)
+	unblock := make(chan struct{})
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "eth_blockNumber" {
+			<-unblock
+		}
+		served <- method
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	require.Zero(t, jail.rpcScheduler.limit)
+	jail.SetMaxConcurrentRPC(1)
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return JSON.stringify(jeth.send({jsonrpc: "2.0", id: 1, method: "eth_call", params: []}).result);
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	// saturate the low lane
+	const lowRequests = 3
+	errc := make(chan error, lowRequests)
+	for i := 0; i < lowRequests; i++ {
+		go func() {
+			_, err := jail.sendRPCCallWithPriority(nil, testBlockNumberRequest, false)
+			errc <- err
+		}()
+	}
+	waitForWaiting(t, jail, lowRequests-1)
+
+	resultc := make(chan string, 1)
+	go func() {
+		resultc <- jail.CallPriority("cell1", `["commands", "call"]`, `{}`, true)
+	}()
+	waitForWaiting(t, jail, lowRequests)
+
+	close(unblock)
+
+	require.Equal(t, "eth_blockNumber", <-served)
+	require.Equal(t, "eth_call", <-served)
+	require.JSONEq(t, `{"result": "0x1"}`, <-resultc)
+
+	for i := 0; i < lowRequests; i++ {
+		require.NoError(t, <-errc)
+	}
+}
+
+// waitForWaiting waits until a given number of RPC requests wait for a slot.
+func waitForWaiting(t *testing.T, jail *Jail, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for jail.rpcScheduler.waiting() != n {
+		if time.Now().After(deadline) {
+			require.FailNow(t, "timed out waiting for RPC requests to be queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
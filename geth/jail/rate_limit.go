@@ -0,0 +1,121 @@
+package jail
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// limitExceededErrorCode is a JSON-RPC error code of rate-limited requests.
+const limitExceededErrorCode = -32005
+
+// ErrLimitExceeded is returned when a cell sends requests faster than allowed.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// tokenBucket limits a rate of requests of a single method.
+// It holds up to rate tokens and is refilled at rate tokens per second.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds tokens accumulated since the last refill.
+func (b *tokenBucket) refill(rate float64, now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > burstSize(rate) {
+		b.tokens = burstSize(rate)
+	}
+	b.last = now
+}
+
+// burstSize returns a number of requests that can be sent at once.
+func burstSize(rate float64) float64 {
+	if rate < 1 {
+		return 1
+	}
+	return rate
+}
+
+// SetRateLimit limits a number of requests of a method a single cell
+// can send per second. Each cell has its own limit, so one chatty DApp
+// doesn't starve others. Requests over the limit get a -32005 error
+// response without being sent to the node. Zero or a negative rate
+// removes the limit; methods without a limit are not throttled.
+func (j *Jail) SetRateLimit(method string, perSecond float64) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	if perSecond <= 0 {
+		delete(j.rateLimits, method)
+		return
+	}
+
+	if j.rateLimits == nil {
+		j.rateLimits = make(map[string]float64)
+	}
+	j.rateLimits[method] = perSecond
+}
+
+// rateLimitedResponse returns an error response if a cell exceeded a rate
+// limit of any method of a raw JSON-RPC payload. A batch is rejected as
+// a whole and takes no tokens in that case. Requests sent by the jail
+// itself rather than by a cell are not limited.
+func (j *Jail) rateLimitedResponse(cell *Cell, request string) (interface{}, bool) {
+	if cell == nil {
+		return nil, false
+	}
+
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	if len(j.rateLimits) == 0 {
+		return nil, false
+	}
+
+	requests, err := decodeRequests(request)
+	if err != nil {
+		return nil, false
+	}
+
+	// number of tokens needed per method
+	needed := make(map[string]float64)
+	for _, req := range requests {
+		if _, ok := j.rateLimits[req.Method]; ok {
+			needed[req.Method]++
+		}
+	}
+	if len(needed) == 0 {
+		return nil, false
+	}
+
+	now := j.now()
+
+	cell.rateMx.Lock()
+	defer cell.rateMx.Unlock()
+
+	if cell.rateBuckets == nil {
+		cell.rateBuckets = make(map[string]*tokenBucket)
+	}
+
+	for method, n := range needed {
+		rate := j.rateLimits[method]
+		bucket, ok := cell.rateBuckets[method]
+		if !ok {
+			bucket = &tokenBucket{tokens: burstSize(rate), last: now}
+			cell.rateBuckets[method] = bucket
+		}
+		bucket.refill(rate, now)
+
+		if bucket.tokens < n {
+			err := fmt.Errorf("%s: %s is limited to %g requests per second", ErrLimitExceeded, method, rate)
+			response, err := newErrorResponses(request, limitExceededErrorCode, err)
+			return response, err == nil
+		}
+	}
+
+	for method, n := range needed {
+		cell.rateBuckets[method].tokens -= n
+	}
+
+	return nil, false
+}
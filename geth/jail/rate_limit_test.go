@@ -0,0 +1,75 @@
+package jail
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRateLimit(t *testing.T) {
+	var requests int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&requests, 1)
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	now := time.Now()
+	jail.now = func() time.Time { return now }
+	jail.SetRateLimit("eth_blockNumber", 5)
+
+	cell1, err := jail.createCell("cell1", false)
+	require.NoError(t, err)
+	cell2, err := jail.createCell("cell2", false)
+	require.NoError(t, err)
+
+	// a burst over the limit is rejected
+	var rejected int
+	for i := 0; i < 20; i++ {
+		response, err := jail.sendRPCCall(cell1, testBlockNumberRequest)
+		require.NoError(t, err)
+
+		if errResponse, ok := response.(map[string]interface{})["error"]; ok {
+			require.Equal(t, map[string]interface{}{
+				"code":    float64(-32005),
+				"message": "limit exceeded: eth_blockNumber is limited to 5 requests per second",
+			}, errResponse)
+			rejected++
+		}
+	}
+	require.Equal(t, 15, rejected)
+	require.Equal(t, int32(5), atomic.LoadInt32(&requests))
+
+	// other cells have their own limits
+	response, err := jail.sendRPCCall(cell2, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.NotContains(t, response, "error")
+
+	// the limit is restored over time
+	now = now.Add(400 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		response, err = jail.sendRPCCall(cell1, testBlockNumberRequest)
+		require.NoError(t, err)
+		require.NotContains(t, response, "error")
+	}
+	response, err = jail.sendRPCCall(cell1, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Contains(t, response, "error")
+
+	// methods without a limit are not throttled
+	response, err = jail.sendRPCCall(cell1, `{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice","params":[]}`)
+	require.NoError(t, err)
+	require.NotContains(t, response, "error")
+
+	jail.SetRateLimit("eth_blockNumber", 0)
+	response, err = jail.sendRPCCall(cell1, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.NotContains(t, response, "error")
+}
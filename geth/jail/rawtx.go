@@ -0,0 +1,144 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+const sendRawTransactionMethod = "eth_sendRawTransaction"
+
+// ErrInvalidRawTransaction is returned when a raw transaction can't be decoded.
+var ErrInvalidRawTransaction = errors.New("invalid raw transaction")
+
+// EnableRawTxValidation enables or disables validation of raw transactions
+// sent with eth_sendRawTransaction. When enabled, each transaction is decoded
+// and its chain ID is checked against the chain of the node
+// before the request is dispatched. Transactions without a chain ID,
+// i.e. signed without EIP-155 replay protection, are rejected unless
+// allowed with AllowUnprotectedRawTxs.
+func (j *Jail) EnableRawTxValidation(enabled bool) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.rawTxValidation = enabled
+}
+
+func (j *Jail) rawTxValidationEnabled() bool {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	return j.rawTxValidation
+}
+
+// AllowUnprotectedRawTxs allows or disallows raw transactions signed
+// without EIP-155 replay protection when raw transactions are validated,
+// see EnableRawTxValidation. Such transactions are valid on any chain,
+// so their chain can't be checked. They are disallowed by default.
+func (j *Jail) AllowUnprotectedRawTxs(allowed bool) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.rawTxUnprotected = allowed
+}
+
+func (j *Jail) unprotectedRawTxsAllowed() bool {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	return j.rawTxUnprotected
+}
+
+// validateRawTransactions validates all eth_sendRawTransaction requests
+// within a raw JSON-RPC payload. Other requests are ignored.
+func (j *Jail) validateRawTransactions(client *rpc.Client, request string) error {
+	requests, err := decodeRequests(request)
+	if err != nil {
+		// Malformed requests are handled by the RPC client.
+		return nil
+	}
+
+	var chainID *big.Int
+	for _, req := range requests {
+		if req.Method != sendRawTransactionMethod {
+			continue
+		}
+
+		tx, err := decodeRawTransaction(req.Params)
+		if err != nil {
+			return err
+		}
+
+		if !tx.Protected() {
+			if j.unprotectedRawTxsAllowed() {
+				continue
+			}
+			return fmt.Errorf("%s: transaction is not replay-protected", ErrInvalidRawTransaction)
+		}
+
+		if chainID == nil {
+			if chainID, err = j.chainID(client); err != nil {
+				return err
+			}
+		}
+
+		if tx.ChainId().Cmp(chainID) != 0 {
+			return fmt.Errorf("%s: chain ID %s does not match chain %s",
+				ErrInvalidRawTransaction, tx.ChainId(), chainID)
+		}
+	}
+
+	return nil
+}
+
+// decodeRawTransaction decodes RLP-encoded transaction from eth_sendRawTransaction params.
+func decodeRawTransaction(params []json.RawMessage) (*types.Transaction, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("%s: expected 1 param, got %d", ErrInvalidRawTransaction, len(params))
+	}
+
+	var data hexutil.Bytes
+	if err := json.Unmarshal(params[0], &data); err != nil {
+		return nil, fmt.Errorf("%s: %v", ErrInvalidRawTransaction, err)
+	}
+
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(data, tx); err != nil {
+		return nil, fmt.Errorf("%s: %v", ErrInvalidRawTransaction, err)
+	}
+
+	return tx, nil
+}
+
+// methodNotFoundErrorCode is a JSON-RPC error code of unknown methods.
+const methodNotFoundErrorCode = -32601
+
+// chainID returns the EIP-155 chain ID of the network the node is connected
+// to, as reported by eth_chainId. The chain ID may differ from the network ID,
+// so the network ID, see NetworkID, is used only if the node doesn't support
+// eth_chainId.
+func (j *Jail) chainID(client *rpc.Client) (*big.Int, error) {
+	var chainID hexutil.Big
+	err := client.Call(&chainID, "eth_chainId")
+	if err == nil {
+		return chainID.ToInt(), nil
+	}
+
+	if rpcErr, ok := err.(gethrpc.Error); !ok || rpcErr.ErrorCode() != methodNotFoundErrorCode {
+		return nil, err
+	}
+
+	networkID, err := j.NetworkID()
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetUint64(networkID), nil
+}
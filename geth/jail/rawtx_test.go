@@ -0,0 +1,136 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func signedRawTransaction(t *testing.T, chainID int64) string {
+	return rawTransaction(t, types.NewEIP155Signer(big.NewInt(chainID)))
+}
+
+func rawTransaction(t *testing.T, signer types.Signer) string {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tx := types.NewTransaction(0, gethcommon.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	tx, err = types.SignTx(tx, signer, key)
+	require.NoError(t, err)
+
+	data, err := rlp.EncodeToBytes(tx)
+	require.NoError(t, err)
+
+	return hexutil.Encode(data)
+}
+
+func TestRawTxValidation(t *testing.T) {
+	var sentTxs int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "net_version":
+			// the network ID differs from the chain ID
+			return "1", nil
+		case "eth_chainId":
+			return "0x3", nil
+		case sendRawTransactionMethod:
+			atomic.AddInt32(&sentTxs, 1)
+			return "0x01", nil
+		}
+		return nil, nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.EnableRawTxValidation(true)
+
+	request := func(rawTx string) string {
+		return `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["` + rawTx + `"]}`
+	}
+
+	// malformed blob is rejected
+	_, err = jail.sendRPCCall(nil, request("0xdeadbeef"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrInvalidRawTransaction.Error())
+
+	// wrong chain is rejected, even if it matches the network ID
+	_, err = jail.sendRPCCall(nil, request(signedRawTransaction(t, 1)))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match chain 3")
+	require.Equal(t, int32(0), atomic.LoadInt32(&sentTxs))
+
+	// valid transaction is dispatched
+	response, err := jail.sendRPCCall(nil, request(signedRawTransaction(t, 3)))
+	require.NoError(t, err)
+	require.Equal(t, "0x01", response.(map[string]interface{})["result"])
+	require.Equal(t, int32(1), atomic.LoadInt32(&sentTxs))
+
+	// transactions without replay protection are rejected by default
+	unprotected := rawTransaction(t, types.HomesteadSigner{})
+	_, err = jail.sendRPCCall(nil, request(unprotected))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not replay-protected")
+	require.Equal(t, int32(1), atomic.LoadInt32(&sentTxs))
+
+	// unless explicitly allowed
+	jail.AllowUnprotectedRawTxs(true)
+	_, err = jail.sendRPCCall(nil, request(unprotected))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&sentTxs))
+
+	// validation disabled
+	jail.EnableRawTxValidation(false)
+	_, err = jail.sendRPCCall(nil, request("0xdeadbeef"))
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&sentTxs))
+}
+
+// testMethodNotFoundError is returned by nodes which don't support a method.
+type testMethodNotFoundError struct{}
+
+func (testMethodNotFoundError) Error() string  { return "method not found" }
+func (testMethodNotFoundError) ErrorCode() int { return -32601 }
+
+func TestRawTxValidationWithoutChainID(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_chainId":
+			return nil, testMethodNotFoundError{}
+		case sendRawTransactionMethod:
+			return "0x01", nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.EnableRawTxValidation(true)
+	jail.SetNetworkID(5)
+
+	request := func(rawTx string) string {
+		return `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["` + rawTx + `"]}`
+	}
+
+	// the configured network ID is used if the node doesn't support eth_chainId
+	_, err = jail.sendRPCCall(nil, request(signedRawTransaction(t, 5)))
+	require.NoError(t, err)
+
+	_, err = jail.sendRPCCall(nil, request(signedRawTransaction(t, 3)))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match chain 5")
+}
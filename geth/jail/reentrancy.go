@@ -0,0 +1,88 @@
+package jail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+)
+
+// ErrReentrantCall is returned when a cell is called from within its own
+// call in progress, e.g. by a Go function called by the cell, which would
+// otherwise wait for the call in progress to finish. Calls made on the
+// goroutine running the call are detected as they are, calls made on other
+// goroutines are detected if they pass CurrentCallContext.
+var ErrReentrantCall = errors.New("reentrant call not allowed")
+
+// callMarker is a key of a context value marking a call of a cell in progress.
+type callMarker struct {
+	cell *Cell
+}
+
+// markCall returns a context of a call of the cell, see ErrReentrantCall.
+func markCall(ctx context.Context, cell *Cell) context.Context {
+	return context.WithValue(ctx, callMarker{cell}, true)
+}
+
+// reentrant returns true if ctx is derived from a context of a call of the cell.
+func reentrant(ctx context.Context, cell *Cell) bool {
+	return ctx.Value(callMarker{cell}) != nil
+}
+
+// setCallOwner records the goroutine running a call of the cell.
+// Zero resets it.
+func (c *Cell) setCallOwner(id uint64) {
+	c.callCtxMx.Lock()
+	defer c.callCtxMx.Unlock()
+
+	c.callOwner = id
+}
+
+// calledBy returns true if a goroutine with id runs a call of the cell,
+// so that calling the cell again would make it wait for itself.
+func (c *Cell) calledBy(id uint64) bool {
+	c.callCtxMx.Lock()
+	defer c.callCtxMx.Unlock()
+
+	return id != 0 && c.callOwner == id
+}
+
+// goroutineID returns an ID of the current goroutine or zero
+// if it can't be determined.
+func goroutineID() uint64 {
+	// The first line of the stack is "goroutine 123 [running]:".
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+
+	id, err := strconv.ParseUint(string(buf), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
+
+// CurrentCallContext returns the context of a call of a cell in progress.
+// Go functions which call the cell on behalf of the call in progress from
+// other goroutines should pass it to CallContext, so that calling the cell fails
+// with ErrReentrantCall right away instead of waiting for the call
+// in progress, which never finishes. RPC requests sent with the context
+// are aborted like those of the call. It returns context.Background()
+// if no call of the cell is in progress.
+func (j *Jail) CurrentCallContext(chatID string) context.Context {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return context.Background()
+	}
+
+	if ctx := cell.callContext(); ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}
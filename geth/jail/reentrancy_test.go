@@ -0,0 +1,55 @@
+package jail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertkrimen/otto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReentrantCall(t *testing.T) {
+	jail := New(nil)
+	defer jail.Stop()
+
+	// don't hang for a minute if reentrancy is not detected
+	jail.SetCellWaitTimeout(5 * time.Second)
+
+	jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(path, args) {
+			return path === "outer" ? callAgain() : 42;
+		}
+	`)
+
+	cell, err := jail.cell("cell1")
+	require.NoError(t, err)
+	err = cell.Set("callAgain", func(call otto.FunctionCall) otto.Value {
+		value, _ := call.Otto.ToValue(jail.CallContext(jail.CurrentCallContext("cell1"), "cell1", "inner", `{}`))
+		return value
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	response := jail.Call("cell1", "outer", `{}`)
+	require.Equal(t, `{"result": {"error":"reentrant call not allowed"}}`, response)
+	require.True(t, time.Since(start) < time.Second, "reentrant call was blocked")
+
+	// plain calls from within the call are detected as well
+	err = cell.Set("callAgain", func(call otto.FunctionCall) otto.Value {
+		value, _ := call.Otto.ToValue(jail.Call("cell1", "inner", `{}`))
+		return value
+	})
+	require.NoError(t, err)
+
+	start = time.Now()
+	response = jail.Call("cell1", "outer", `{}`)
+	require.Equal(t, `{"result": {"error":"reentrant call not allowed"}}`, response)
+	require.True(t, time.Since(start) < time.Second, "reentrant call was blocked")
+
+	// the cell can be called once the call finished
+	require.Equal(t, `{"result": 42}`, jail.Call("cell1", "inner", `{}`))
+
+	// as well as with the context once no call is in progress
+	require.Equal(t, `{"result": 42}`, jail.CallContext(jail.CurrentCallContext("cell1"), "cell1", "inner", `{}`))
+}
@@ -0,0 +1,237 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// JSON-RPC error codes of malformed requests.
+const (
+	parseErrorCode     = -32700
+	invalidRequestCode = -32600
+)
+
+var (
+	errParseError     = errors.New("parse error")
+	errInvalidRequest = errors.New("invalid request")
+)
+
+// rpcRequest is a single JSON-RPC request as sent by web3.js.
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// rpcResponse is a single JSON-RPC response.
+type rpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// jsonrpcError is an error object of a JSON-RPC response.
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// jsonrpcErrorResponse is a JSON-RPC response with an error.
+type jsonrpcErrorResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   jsonrpcError    `json:"error"`
+}
+
+// jsonrpcResultResponse is a successful JSON-RPC response.
+type jsonrpcResultResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// newErrorResponses returns JSON-RPC error responses to a raw JSON-RPC payload
+// decoded like other responses. If the payload is a batch, each request gets
+// a response with the same error.
+func newErrorResponses(request string, code int, err error) (interface{}, error) {
+	requests, decodeErr := decodeRequests(request)
+	if decodeErr != nil || len(requests) == 0 {
+		requests = []rpcRequest{{}}
+	}
+
+	responses := make([]jsonrpcErrorResponse, len(requests))
+	for i, req := range requests {
+		id := req.ID
+		if len(id) == 0 {
+			id = json.RawMessage(`0`)
+		}
+
+		responses[i] = jsonrpcErrorResponse{
+			Version: "2.0",
+			ID:      id,
+			Error:   jsonrpcError{Code: code, Message: err.Error()},
+		}
+	}
+
+	var (
+		data       []byte
+		marshalErr error
+	)
+	if isBatchRequest(request) && decodeErr == nil {
+		data, marshalErr = json.Marshal(responses)
+	} else {
+		data, marshalErr = json.Marshal(responses[0])
+	}
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	var response interface{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// restoreResponseIDs replaces IDs of marshalled responses with the raw IDs
+// of the corresponding requests. Responses are decoded with numbers as float64,
+// so large integer IDs would lose precision otherwise. Responses are left
+// as they are if they don't match the requests.
+func restoreResponseIDs(request string, data []byte) []byte {
+	requests, err := decodeRequests(request)
+	if err != nil {
+		return data
+	}
+
+	batch := isBatchRequest(request)
+	var responses []map[string]json.RawMessage
+	if batch {
+		err = json.Unmarshal(data, &responses)
+	} else {
+		responses = make([]map[string]json.RawMessage, 1)
+		err = json.Unmarshal(data, &responses[0])
+	}
+	if err != nil || len(responses) != len(requests) {
+		return data
+	}
+
+	for i, response := range responses {
+		if len(requests[i].ID) == 0 || !sameID(response["id"], requests[i].ID) {
+			continue
+		}
+		response["id"] = requests[i].ID
+	}
+
+	var restored []byte
+	if batch {
+		restored, err = json.Marshal(responses)
+	} else {
+		restored, err = json.Marshal(responses[0])
+	}
+	if err != nil {
+		return data
+	}
+
+	return restored
+}
+
+// sameID returns true if two raw IDs are equal once decoded.
+func sameID(a, b json.RawMessage) bool {
+	var idA, idB interface{}
+	if json.Unmarshal(a, &idA) != nil || json.Unmarshal(b, &idB) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(idA, idB)
+}
+
+// malformedRequestResponse returns an error response if a raw JSON-RPC payload
+// is empty, is not valid JSON, or is not a request or a non-empty batch.
+// It returns false if the payload is well-formed.
+func malformedRequestResponse(request string) (interface{}, bool) {
+	if strings.TrimSpace(request) == "" || !json.Valid([]byte(request)) {
+		response, err := newErrorResponses("", parseErrorCode, errParseError)
+		return response, err == nil
+	}
+
+	requests, err := decodeRequests(request)
+	if err == nil && len(requests) > 0 {
+		return nil, false
+	}
+
+	// An empty batch gets a single response.
+	response, err := newErrorResponses("", invalidRequestCode, errInvalidRequest)
+	return response, err == nil
+}
+
+// decodeRequests unmarshals a raw JSON-RPC payload, which may be either
+// a single request or a batch, into a slice of requests.
+func decodeRequests(request string) ([]rpcRequest, error) {
+	if isBatchRequest(request) {
+		var requests []rpcRequest
+		if err := json.Unmarshal([]byte(request), &requests); err != nil {
+			return nil, err
+		}
+
+		return requests, nil
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal([]byte(request), &req); err != nil {
+		return nil, err
+	}
+
+	return []rpcRequest{req}, nil
+}
+
+// isBatchRequest returns true if a raw JSON-RPC payload is a batch.
+func isBatchRequest(request string) bool {
+	return strings.HasPrefix(strings.TrimSpace(request), "[")
+}
+
+// rewriteRequests decodes a raw JSON-RPC payload, calls fn for each
+// request and encodes the payload back, preserving its batch form.
+func rewriteRequests(request string, fn func(msg map[string]json.RawMessage) error) (string, error) {
+	batch := isBatchRequest(request)
+
+	var msgs []map[string]json.RawMessage
+	if batch {
+		if err := json.Unmarshal([]byte(request), &msgs); err != nil {
+			return "", err
+		}
+	} else {
+		var msg map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(request), &msg); err != nil {
+			return "", err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	for _, msg := range msgs {
+		if err := fn(msg); err != nil {
+			return "", err
+		}
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if batch {
+		data, err = json.Marshal(msgs)
+	} else {
+		data, err = json.Marshal(msgs[0])
+	}
+
+	return string(data), err
+}
+
+// requestMethod returns a method of a request decoded by rewriteRequests.
+func requestMethod(msg map[string]json.RawMessage) string {
+	var method string
+	json.Unmarshal(msg["method"], &method) //nolint: errcheck
+	return method
+}
@@ -0,0 +1,99 @@
+package jail
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendMalformedRequests(t *testing.T) {
+	var requests int32
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&requests, 1)
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	errorResponse := func(code float64, message string) interface{} {
+		return map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      float64(0),
+			"error": map[string]interface{}{
+				"code":    code,
+				"message": message,
+			},
+		}
+	}
+
+	cases := []struct {
+		name     string
+		request  string
+		expected interface{}
+	}{
+		{"empty", "", errorResponse(-32700, "parse error")},
+		{"whitespace", " \n", errorResponse(-32700, "parse error")},
+		{"malformed", `{"jsonrpc":"2.0","id":1,"method":`, errorResponse(-32700, "parse error")},
+		{"truncated_batch", `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]},`, errorResponse(-32700, "parse error")},
+		{"undefined", "undefined", errorResponse(-32700, "parse error")},
+		{"empty_batch", "[]", errorResponse(-32600, "invalid request")},
+		{"not_request", "42", errorResponse(-32600, "invalid request")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := jail.sendRPCCall(nil, tc.request)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, response)
+			require.False(t, isTransportFailure(response, err))
+		})
+	}
+	require.Equal(t, int32(0), atomic.LoadInt32(&requests))
+
+	// jeth.send without a payload doesn't panic
+	_, err = jail.createAndInitCell("cell1")
+	require.NoError(t, err)
+	cell, err := jail.Cell("cell1")
+	require.NoError(t, err)
+
+	value, err := cell.Run(`JSON.stringify(jeth.send())`)
+	require.NoError(t, err)
+	require.Equal(t, `{"error":{"code":-32700,"message":"parse error"},"id":0,"jsonrpc":"2.0"}`, value.String())
+}
+
+func TestResponseIDs(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return "0x10", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	require.NotContains(t, jail.Parse("cell1", `var _status_catalog = {};`), "error")
+
+	// responses to cells keep types of IDs
+	send := `var response = web3.currentProvider.send({jsonrpc: "2.0", id: %s, method: "eth_blockNumber", params: []});
+		typeof response.id + " " + JSON.stringify(response.id)`
+	require.Equal(t, `number 42`, jail.Execute("cell1", fmt.Sprintf(send, `42`)))
+	require.Equal(t, `string "abc"`, jail.Execute("cell1", fmt.Sprintf(send, `"abc"`)))
+
+	// raw responses echo IDs unchanged, even if they don't fit float64
+	for _, id := range []string{`42`, `"abc"`, `9007199254740993`} {
+		response, err := jail.SendRaw(`{"jsonrpc":"2.0","id":` + id + `,"method":"eth_blockNumber","params":[]}`)
+		require.NoError(t, err)
+		require.Equal(t, `{"id":`+id+`,"jsonrpc":"2.0","result":"0x10"}`, response)
+	}
+
+	response, err := jail.SendRaw(`[{"jsonrpc":"2.0","id":9007199254740993,"method":"eth_blockNumber","params":[]},{"jsonrpc":"2.0","id":"abc","method":"eth_blockNumber","params":[]}]`)
+	require.NoError(t, err)
+	require.Equal(t, `[{"id":9007199254740993,"jsonrpc":"2.0","result":"0x10"},{"id":"abc","jsonrpc":"2.0","result":"0x10"}]`, response)
+}
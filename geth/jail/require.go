@@ -0,0 +1,76 @@
+package jail
+
+import (
+	"github.com/robertkrimen/otto"
+)
+
+// requireResolverCode wraps require() provided by web3.js, so that modules
+// are resolved through the host resolver first. Resolved modules are
+// evaluated as CommonJS modules and cached per cell.
+const requireResolverCode = `
+	(function() {
+		var bundledRequire = require;
+		var cache = {};
+		require = function(name, jumped) {
+			if (Object.prototype.hasOwnProperty.call(cache, name)) {
+				return cache[name].exports;
+			}
+			var code = _status_resolveModule(name);
+			if (code === undefined) {
+				// The bundled require() falls back to the global one for unknown
+				// modules, passing "jumped" to prevent an infinite recursion.
+				return bundledRequire(name, jumped);
+			}
+			var module = {exports: {}};
+			cache[name] = module;
+			try {
+				new Function("module", "exports", "require", code)(module, module.exports, require);
+			} catch (e) {
+				delete cache[name];
+				throw e;
+			}
+			return module.exports;
+		};
+	})();
+`
+
+// SetRequireResolver sets a function used by require() in cells
+// to resolve modules which are not bundled with web3.js.
+// The function returns JavaScript code of a module or false
+// if the module is not known. Nil removes the resolver.
+func (j *Jail) SetRequireResolver(fn func(moduleName string) (js string, ok bool)) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.requireResolver = fn
+}
+
+func (j *Jail) resolveModule(name string) (string, bool) {
+	j.settingsMx.RLock()
+	resolver := j.requireResolver
+	j.settingsMx.RUnlock()
+
+	if resolver == nil {
+		return "", false
+	}
+
+	return resolver(name)
+}
+
+// registerRequireResolver creates a function called "_status_resolveModule",
+// which returns code of a module resolved by the jail's resolver or undefined.
+func registerRequireResolver(jail *Jail, cell *Cell) error {
+	return cell.Set("_status_resolveModule", func(call otto.FunctionCall) otto.Value {
+		code, ok := jail.resolveModule(call.Argument(0).String())
+		if !ok {
+			return otto.UndefinedValue()
+		}
+
+		value, err := call.Otto.ToValue(code)
+		if err != nil {
+			throwJSError(err)
+		}
+
+		return value
+	})
+}
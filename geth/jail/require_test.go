@@ -0,0 +1,60 @@
+package jail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireResolver(t *testing.T) {
+	jail := New(nil)
+
+	var resolved int
+	jail.SetRequireResolver(func(moduleName string) (string, bool) {
+		if moduleName != "greeter" {
+			return "", false
+		}
+		resolved++
+		return `
+			var prefix = require("prefix");
+			module.exports = function(name) { return prefix + name; };
+		`, true
+	})
+
+	_, err := jail.createAndInitCell("cell1")
+	require.NoError(t, err)
+	cell, err := jail.Cell("cell1")
+	require.NoError(t, err)
+
+	// a module with a dependency which is not resolved fails
+	_, err = cell.Run(`require("greeter")("world")`)
+	require.Error(t, err)
+
+	jail.SetRequireResolver(func(moduleName string) (string, bool) {
+		switch moduleName {
+		case "greeter":
+			resolved++
+			return `
+				var prefix = require("prefix");
+				module.exports = function(name) { return prefix + name; };
+			`, true
+		case "prefix":
+			return `module.exports = "hello, ";`, true
+		}
+		return "", false
+	})
+
+	value, err := cell.Run(`require("greeter")("world")`)
+	require.NoError(t, err)
+	require.Equal(t, "hello, world", value.String())
+
+	// modules are cached per cell, failed ones are not
+	_, err = cell.Run(`require("greeter")`)
+	require.NoError(t, err)
+	require.Equal(t, 2, resolved)
+
+	// bundled modules are still available
+	value, err = cell.Run(`typeof require("web3")`)
+	require.NoError(t, err)
+	require.Equal(t, "function", value.String())
+}
@@ -0,0 +1,63 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrMissingCommands is returned when a catalog lacks required commands.
+var ErrMissingCommands = errors.New("missing required commands")
+
+// findMissingCommandsCode returns names of required commands
+// which are neither in `_status_catalog.commands` nor in `_status_catalog`.
+const findMissingCommandsCode = `
+	(function(names) {
+		var missing = [];
+		names.forEach(function(name) {
+			var catalog = _status_catalog;
+			if (!(catalog.commands && catalog.commands[name]) && !catalog[name]) {
+				missing.push(name);
+			}
+		});
+		return missing.join(", ");
+	})(%s)
+`
+
+// RequireCommands sets commands which must be defined in the catalog of each cell.
+// A command is looked up in `_status_catalog.commands` and `_status_catalog`.
+// If any of them is missing, Parse and CreateAndInitCell return an error.
+func (j *Jail) RequireCommands(names []string) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.requiredCommands = names
+}
+
+// checkRequiredCommands returns an error if the catalog
+// of a cell lacks any required command.
+func (j *Jail) checkRequiredCommands(cell *Cell) error {
+	j.settingsMx.RLock()
+	names := j.requiredCommands
+	j.settingsMx.RUnlock()
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+
+	missing, err := cell.Run(fmt.Sprintf(findMissingCommandsCode, data))
+	if err != nil {
+		return err
+	}
+
+	if missing.String() != "" {
+		return fmt.Errorf("%s: %s", ErrMissingCommands, missing)
+	}
+
+	return nil
+}
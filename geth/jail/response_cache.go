@@ -0,0 +1,344 @@
+package jail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// blockTagParams maps block-sensitive methods which can be cached
+// to the index of their block tag parameter.
+var blockTagParams = map[string]int{
+	"eth_call":                1,
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_getStorageAt":        2,
+}
+
+// maxResponseCacheSize is the maximum number of results in the response cache.
+// Results at the lowest blocks are evicted first, starting with results
+// which aren't cached by block.
+const maxResponseCacheSize = 1000
+
+// responseCache caches results of requests according to policies of their
+// methods. Results of block-sensitive methods, see EnableResponseCache, are
+// keyed by the network ID and the block number the request is resolved
+// against, thus results are never served across networks or blocks.
+// Results of methods set with SetCacheableMethods are cached for a TTL
+// and discarded when the RPC client changes.
+type responseCache struct {
+	mx      sync.Mutex
+	enabled bool   // true if results of block-sensitive methods are cached
+	block   uint64 // the latest block number seen
+	methods map[string]bool
+	ttl     time.Duration
+	client  *rpc.Client // client results of methods were obtained with
+	results map[string]cachedResult
+}
+
+// responseCacheSlot identifies a result of a request in the response cache.
+type responseCacheSlot struct {
+	key    string
+	block  uint64
+	latest bool // true if the block was resolved from "latest"
+	timed  bool // true if the result is cached for a TTL
+}
+
+// cachedResult is a cached result of a request.
+type cachedResult struct {
+	block   uint64
+	latest  bool // true if the block was resolved from "latest"
+	timed   bool // true if the result is cached for a TTL
+	expires time.Time
+	result  json.RawMessage
+}
+
+// expired returns true if a result cached for a TTL expired.
+func (r cachedResult) expired(now time.Time) bool {
+	return r.timed && !r.expires.IsZero() && !now.Before(r.expires)
+}
+
+// EnableResponseCache enables or disables caching of results
+// of block-sensitive requests, like eth_call and eth_getBalance.
+// Requests at the "latest" block are sent at the block number returned
+// by BlockNumber and cached until it advances. Requests at "pending"
+// are not cached. At most maxResponseCacheSize results are cached.
+func (j *Jail) EnableResponseCache(enabled bool) {
+	cache := &j.responseCache
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	cache.enabled = enabled
+	cache.dropResults(func(cached cachedResult) bool { return !cached.timed })
+}
+
+// SetCacheableMethods sets methods, like net_version and eth_chainId,
+// whose results are cached for ttl, keyed by the method and params.
+// Cached results are discarded when the RPC client changes, e.g. when
+// the node is restarted. Zero ttl caches results until then.
+// Responses served from the cache carry the ID of the request.
+// Batches are not cached. No methods are cached by default.
+// Block-sensitive methods are cached by block instead if the response
+// cache is enabled, see EnableResponseCache.
+func (j *Jail) SetCacheableMethods(methods []string, ttl time.Duration) {
+	cache := &j.responseCache
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	cache.methods = make(map[string]bool, len(methods))
+	for _, method := range methods {
+		cache.methods[method] = true
+	}
+	cache.ttl = ttl
+	cache.client = nil
+	cache.dropResults(func(cached cachedResult) bool { return cached.timed })
+}
+
+// dropResults drops cached results matching a function.
+// It must be called with mx held.
+func (c *responseCache) dropResults(match func(cachedResult) bool) {
+	for key, cached := range c.results {
+		if match(cached) {
+			delete(c.results, key)
+		}
+	}
+}
+
+// responseCacheKey returns a cache slot of a single request sent with client.
+// It returns false if the request can't be cached.
+func (j *Jail) responseCacheKey(client *rpc.Client, request string) (slot responseCacheSlot, resolved string, ok bool) {
+	req, ok := singleRequest(request)
+	if !ok {
+		return responseCacheSlot{}, "", false
+	}
+
+	cache := &j.responseCache
+	cache.mx.Lock()
+	_, blockSensitive := blockTagParams[req.Method]
+	byBlock := cache.enabled && blockSensitive
+	timed := !byBlock && cache.methods[req.Method]
+	if timed && cache.client != client {
+		cache.client = client
+		cache.dropResults(func(cached cachedResult) bool { return cached.timed })
+	}
+	cache.mx.Unlock()
+
+	switch {
+	case byBlock:
+		return j.blockCacheKey(req, request)
+	case timed:
+		params, err := json.Marshal(req.Params)
+		if err != nil {
+			return responseCacheSlot{}, "", false
+		}
+		return responseCacheSlot{key: req.Method + ":" + string(params), timed: true}, request, true
+	}
+
+	return responseCacheSlot{}, "", false
+}
+
+// blockCacheKey returns a cache slot of a block-sensitive request and the
+// request with the "latest" block tag replaced by the block number of the
+// slot, so that the cached result is the one at that block.
+// It returns false if the request can't be cached.
+func (j *Jail) blockCacheKey(req rpcRequest, request string) (slot responseCacheSlot, resolved string, ok bool) {
+	index := blockTagParams[req.Method]
+
+	params := make([]json.RawMessage, len(req.Params))
+	copy(params, req.Params)
+
+	var tag string
+	if index < len(params) {
+		if err := json.Unmarshal(params[index], &tag); err != nil {
+			return responseCacheSlot{}, "", false
+		}
+	} else if index == len(params) {
+		tag = "latest"
+	} else {
+		return responseCacheSlot{}, "", false
+	}
+
+	var (
+		block uint64
+		err   error
+	)
+	switch tag {
+	case "latest":
+		if block, err = j.BlockNumber(context.Background()); err != nil {
+			return responseCacheSlot{}, "", false
+		}
+		j.advanceResponseCache(block)
+		slot.latest = true
+	case "pending", "earliest":
+		return responseCacheSlot{}, "", false
+	default:
+		number, err := hexutil.DecodeUint64(tag)
+		if err != nil {
+			return responseCacheSlot{}, "", false
+		}
+		block = number
+	}
+
+	networkID, err := j.NetworkID()
+	if err != nil {
+		return responseCacheSlot{}, "", false
+	}
+
+	resolved = request
+	if slot.latest {
+		number, err := json.Marshal(hexutil.Uint64(block))
+		if err != nil {
+			return responseCacheSlot{}, "", false
+		}
+
+		resolved, err = rewriteRequests(request, func(msg map[string]json.RawMessage) error {
+			var err error
+			msg["params"], err = json.Marshal(append(params[:index:index], number))
+			return err
+		})
+		if err != nil {
+			return responseCacheSlot{}, "", false
+		}
+	}
+
+	data, err := json.Marshal(params[:index])
+	if err != nil {
+		return responseCacheSlot{}, "", false
+	}
+
+	slot.key = fmt.Sprintf("%d:%s:%d:%s", networkID, req.Method, block, data)
+	slot.block = block
+
+	return slot, resolved, true
+}
+
+// advanceResponseCache drops results at the "latest" block
+// if the block number advances.
+func (j *Jail) advanceResponseCache(block uint64) {
+	cache := &j.responseCache
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	if block <= cache.block {
+		return
+	}
+	cache.block = block
+
+	for key, cached := range cache.results {
+		if cached.latest {
+			delete(cache.results, key)
+		}
+	}
+}
+
+// cachedResponse returns a cached response to a request with a given ID.
+func (j *Jail) cachedResponse(slot responseCacheSlot, request string) (interface{}, bool) {
+	cache := &j.responseCache
+	cache.mx.Lock()
+	cached, ok := cache.results[slot.key]
+	if ok && cached.expired(j.now()) {
+		delete(cache.results, slot.key)
+		ok = false
+	}
+	cache.mx.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	return responseWithResult(request, cached.result)
+}
+
+// responseWithResult returns a successful response to a single request
+// with a given result, carrying the ID of the request.
+func responseWithResult(request string, result json.RawMessage) (interface{}, bool) {
+	requests, err := decodeRequests(request)
+	if err != nil || len(requests) != 1 {
+		return nil, false
+	}
+
+	id := requests[0].ID
+	if len(id) == 0 {
+		id = json.RawMessage(`0`)
+	}
+
+	data, err := json.Marshal(jsonrpcResultResponse{Version: "2.0", ID: id, Result: result})
+	if err != nil {
+		return nil, false
+	}
+
+	var response interface{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+
+	return response, true
+}
+
+// cacheResponse caches a result of a successful response obtained with client.
+func (j *Jail) cacheResponse(client *rpc.Client, slot responseCacheSlot, rawResponse string) {
+	result, ok := successfulResult(rawResponse)
+	if !ok {
+		return
+	}
+
+	cache := &j.responseCache
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	// Caching was reconfigured or the client changed in the meantime.
+	if slot.timed && cache.client != client || !slot.timed && !cache.enabled {
+		return
+	}
+
+	if cache.results == nil {
+		cache.results = make(map[string]cachedResult)
+	}
+	if _, ok := cache.results[slot.key]; !ok && len(cache.results) >= maxResponseCacheSize {
+		cache.evictLowestBlock()
+	}
+
+	cached := cachedResult{block: slot.block, latest: slot.latest, timed: slot.timed, result: result}
+	if slot.timed && cache.ttl > 0 {
+		cached.expires = j.now().Add(cache.ttl)
+	}
+	cache.results[slot.key] = cached
+}
+
+// evictLowestBlock drops results at the lowest block in the cache.
+// It must be called with mx held.
+func (c *responseCache) evictLowestBlock() {
+	lowest := uint64(math.MaxUint64)
+	for _, cached := range c.results {
+		if cached.block < lowest {
+			lowest = cached.block
+		}
+	}
+
+	for key, cached := range c.results {
+		if cached.block == lowest {
+			delete(c.results, key)
+		}
+	}
+}
+
+// successfulResult returns the result of a single response.
+// It returns false if the response is an error.
+func successfulResult(rawResponse string) (json.RawMessage, bool) {
+	var response rpcResponse
+	if err := json.Unmarshal([]byte(rawResponse), &response); err != nil {
+		return nil, false
+	}
+	if len(response.Error) > 0 && string(response.Error) != "null" {
+		return nil, false
+	}
+
+	return response.Result, true
+}
@@ -0,0 +1,201 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	var (
+		mx    sync.Mutex
+		block uint64 = 100
+		calls int
+		tags  []string
+	)
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		mx.Lock()
+		defer mx.Unlock()
+
+		switch method {
+		case "eth_blockNumber":
+			return hexutil.Uint64(block), nil
+		case "net_version":
+			return "1", nil
+		case "eth_call":
+			calls++
+			var tag string
+			require.NoError(t, json.Unmarshal(params[1], &tag))
+			tags = append(tags, tag)
+			return hexutil.Uint64(block), nil
+		}
+		return nil, errors.New("unexpected method " + method)
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.SetBlockNumberTTL(0)
+	jail.EnableResponseCache(true)
+
+	ethCall := func(id int, tag string) interface{} {
+		request := `{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"eth_call","params":[{"to":"0x65c01586aa0ce152c2701b2ad1162b8d859a7234"},"` + tag + `"]}`
+		response, err := jail.sendRPCCall(nil, request)
+		require.NoError(t, err)
+		require.Equal(t, float64(id), response.(map[string]interface{})["id"])
+		return response.(map[string]interface{})["result"]
+	}
+	callCount := func() int {
+		mx.Lock()
+		defer mx.Unlock()
+		return calls
+	}
+
+	// the result is reused within the same block
+	require.Equal(t, "0x64", ethCall(1, "latest"))
+	require.Equal(t, "0x64", ethCall(2, "latest"))
+	require.Equal(t, 1, callCount())
+
+	// the request is sent at the block number it's cached at
+	require.Equal(t, []string{"0x64"}, tags)
+
+	// and refetched after the block number increments
+	mx.Lock()
+	block++
+	mx.Unlock()
+	require.Equal(t, "0x65", ethCall(3, "latest"))
+	require.Equal(t, 2, callCount())
+
+	// explicit block numbers are cached as well
+	require.Equal(t, "0x65", ethCall(4, "0x64"))
+	require.Equal(t, "0x65", ethCall(5, "0x64"))
+	require.Equal(t, 3, callCount())
+
+	// pending state is never cached
+	ethCall(6, "pending")
+	ethCall(7, "pending")
+	require.Equal(t, 5, callCount())
+
+	// results are not served across networks
+	jail.SetNetworkID(3)
+	require.Equal(t, "0x65", ethCall(8, "0x64"))
+	require.Equal(t, 6, callCount())
+}
+
+func TestResponseCacheSize(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "net_version" {
+			return "1", nil
+		}
+		return "0x1", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	jail.EnableResponseCache(true)
+
+	for block := 1; block <= maxResponseCacheSize+10; block++ {
+		request := `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x65c01586aa0ce152c2701b2ad1162b8d859a7234","` + hexutil.EncodeUint64(uint64(block)) + `"]}`
+		_, err := jail.sendRPCCall(nil, request)
+		require.NoError(t, err)
+	}
+
+	jail.responseCache.mx.Lock()
+	defer jail.responseCache.mx.Unlock()
+
+	require.Len(t, jail.responseCache.results, maxResponseCacheSize)
+	for _, cached := range jail.responseCache.results {
+		require.True(t, cached.block > 10, "results at the lowest blocks are evicted first")
+	}
+}
+
+func TestMethodCache(t *testing.T) {
+	handler := func(requests *int32) testRPCHandler {
+		return func(method string, params []json.RawMessage) (interface{}, error) {
+			atomic.AddInt32(requests, 1)
+			switch method {
+			case "net_version":
+				return "1", nil
+			case "eth_chainId":
+				return "0x1", nil
+			}
+			return nil, errors.New("unexpected method " + method)
+		}
+	}
+
+	var requests1, requests2 int32
+	ts1 := newTestRPCServer(handler(&requests1))
+	defer ts1.Close()
+	ts2 := newTestRPCServer(handler(&requests2))
+	defer ts2.Close()
+
+	provider1, err := newTestRPCClientProvider(ts1.URL)
+	require.NoError(t, err)
+	provider2, err := newTestRPCClientProvider(ts2.URL)
+	require.NoError(t, err)
+
+	provider := &testRPCClientProvider{provider1.rpcClient}
+	jail := New(provider)
+
+	now := time.Now()
+	jail.now = func() time.Time { return now }
+
+	send := func(id int, method string) interface{} {
+		request := `{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"` + method + `","params":[]}`
+		response, err := jail.sendRPCCall(nil, request)
+		require.NoError(t, err)
+		require.Equal(t, float64(id), response.(map[string]interface{})["id"])
+		return response.(map[string]interface{})["result"]
+	}
+
+	// nothing is cached by default
+	require.Equal(t, "1", send(1, "net_version"))
+	require.Equal(t, "1", send(2, "net_version"))
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests1))
+
+	jail.SetCacheableMethods([]string{"net_version"}, time.Minute)
+
+	// cached responses carry IDs of requests
+	require.Equal(t, "1", send(3, "net_version"))
+	require.Equal(t, "1", send(4, "net_version"))
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests1))
+
+	// other methods are not cached
+	require.Equal(t, "0x1", send(5, "eth_chainId"))
+	require.Equal(t, "0x1", send(6, "eth_chainId"))
+	require.Equal(t, int32(5), atomic.LoadInt32(&requests1))
+
+	// results expire
+	now = now.Add(time.Minute)
+	require.Equal(t, "1", send(7, "net_version"))
+	require.Equal(t, int32(6), atomic.LoadInt32(&requests1))
+
+	// and are discarded when the client changes
+	provider.rpcClient = provider2.rpcClient
+	require.Equal(t, "1", send(8, "net_version"))
+	require.Equal(t, "1", send(9, "net_version"))
+	require.Equal(t, int32(6), atomic.LoadInt32(&requests1))
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests2))
+
+	// enabling caching by block keeps them
+	jail.EnableResponseCache(true)
+	require.Equal(t, "1", send(10, "net_version"))
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests2))
+
+	// while reconfiguring methods discards them
+	jail.SetCacheableMethods([]string{"net_version"}, time.Minute)
+	require.Equal(t, "1", send(11, "net_version"))
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests2))
+}
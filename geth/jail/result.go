@@ -0,0 +1,22 @@
+package jail
+
+import (
+	"github.com/robertkrimen/otto"
+)
+
+// formatCallResult prepares a value returned from a cell to be put into
+// a JSON response. Objects are serialized with JSON.stringify as otherwise
+// they are turned into "[object Object]". BigNumber values are serialized
+// to their decimal string form with statusJSONReplacer.
+func formatCallResult(cell *Cell, value otto.Value) (otto.Value, error) {
+	if !value.IsObject() {
+		return value, nil
+	}
+
+	replacer, err := cell.Get("statusJSONReplacer")
+	if err != nil {
+		return value, err
+	}
+
+	return cell.Call("JSON.stringify", nil, value, replacer)
+}
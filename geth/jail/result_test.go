@@ -0,0 +1,64 @@
+package jail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallBigNumberResult(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return bn("1000000000000000000000");
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	result := jail.Call("cell1", `["commands", "balance"]`, `{}`)
+	require.Equal(t, `{"result": "1000000000000000000000"}`, result)
+
+	// nested BigNumber values can be serialized with statusJSONReplacer
+	response = jail.Parse("cell2", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return JSON.stringify({value: bn(42)}, statusJSONReplacer);
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	result = jail.Call("cell2", `["commands", "balance"]`, `{}`)
+	require.Equal(t, `{"result": {"value":"42"}}`, result)
+}
+
+func TestCallObjectResult(t *testing.T) {
+	jail := New(nil)
+
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		function call(pathStr, paramsStr) {
+			return {name: "status", count: 2, nested: {ok: true}};
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	result := jail.Call("cell1", `["commands", "info"]`, `{}`)
+	require.NotContains(t, result, "[object Object]")
+
+	var decoded struct {
+		Result struct {
+			Name   string `json:"name"`
+			Count  int    `json:"count"`
+			Nested struct {
+				OK bool `json:"ok"`
+			} `json:"nested"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result), &decoded))
+	require.Equal(t, "status", decoded.Result.Name)
+	require.Equal(t, 2, decoded.Result.Count)
+	require.True(t, decoded.Result.Nested.OK)
+}
@@ -0,0 +1,75 @@
+package jail
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// callRetryBackoff is a delay before the first retry of CallWithRetry.
+// It doubles with each attempt.
+var callRetryBackoff = 100 * time.Millisecond
+
+// CallWithRetry works like Call, but it calls the command again, up to
+// the given number of attempts, if it fails due to an RPC transport failure,
+// like a network error. Errors returned by the node and JavaScript exceptions
+// which are not caused by such a failure are not retried.
+// It should be used only with idempotent commands.
+func (j *Jail) CallWithRetry(chatID, commandPath, args string, attempts int) string {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	backoff := callRetryBackoff
+	for attempt := 1; ; attempt++ {
+		atomic.StoreInt32(&cell.rpcFailed, 0)
+
+		value, err := j.callCell(cell, commandPath, args, callOptions{})
+		if err == nil {
+			return newJailResultResponse(value.String())
+		}
+
+		if attempt >= attempts || atomic.LoadInt32(&cell.rpcFailed) == 0 {
+			return newJailErrorResponse(err)
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// isTransportFailure returns true if a response returned by sendRPCCall
+// or its error is caused by an RPC transport failure.
+func isTransportFailure(response interface{}, err error) bool {
+	if err == ErrNoRPCClient {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+
+	responses, ok := response.([]interface{})
+	if !ok {
+		responses = []interface{}{response}
+	}
+
+	for _, r := range responses {
+		msg, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		rpcErr, ok := msg["error"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// rpc.Client.CallRaw marks failures to reach a remote node,
+		// like connection errors, with {"transport": true} data.
+		if data, ok := rpcErr["data"].(map[string]interface{}); ok && data["transport"] == true {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallWithRetry(t *testing.T) {
+	defer func(backoff time.Duration) { callRetryBackoff = backoff }(callRetryBackoff)
+	callRetryBackoff = time.Millisecond
+
+	var requests int32
+	handler := newTestRPCHTTPHandler(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "eth_call" {
+			return nil, errors.New("execution reverted")
+		}
+		return "0x10", nil
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the connection of the first request is dropped
+		if atomic.AddInt32(&requests, 1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		handler(w, r)
+	}))
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+	response := jail.Parse("cell1", `
+		var _status_catalog = {};
+		var attempts = 0;
+		function call(pathStr, paramsStr) {
+			attempts++;
+			if (pathStr === "throw") {
+				throw new Error("command failed");
+			}
+			if (pathStr === "revert") {
+				return web3.eth.call({to: "0x65c01586aa0ce152c2701b2ad1162b8d859a7234"});
+			}
+			return web3.eth.blockNumber;
+		}
+	`)
+	require.NotContains(t, response, "error")
+
+	// the transport failure is retried
+	require.Equal(t, `{"result": 16}`, jail.CallWithRetry("cell1", "block", `{}`, 3))
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// JS exceptions are not retried
+	jail.Execute("cell1", "attempts = 0")
+	require.Equal(t, `{"error":"Error: command failed"}`, jail.CallWithRetry("cell1", "throw", `{}`, 3))
+	require.Equal(t, "1", jail.Execute("cell1", "attempts"))
+
+	// errors returned by the node are not retried
+	jail.Execute("cell1", "attempts = 0")
+	require.Equal(t, `{"error":"Error: execution reverted"}`, jail.CallWithRetry("cell1", "revert", `{}`, 3))
+	require.Equal(t, "1", jail.Execute("cell1", "attempts"))
+}
@@ -0,0 +1,31 @@
+package jail
+
+import (
+	"context"
+
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// SetRPCObserver sets a function notified about each JSON-RPC request
+// cells send to the node, e.g. to collect metrics. Requests of a batch
+// are reported one by one. Requests which don't reach the node, like
+// blocked or cached ones, are not reported. Nil removes the observer.
+func (j *Jail) SetRPCObserver(observer rpc.CallObserver) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.rpcObserver = observer
+}
+
+// withRPCObserver returns a context notifying the RPC observer, if it's set.
+func (j *Jail) withRPCObserver(ctx context.Context) context.Context {
+	j.settingsMx.RLock()
+	observer := j.rpcObserver
+	j.settingsMx.RUnlock()
+
+	if observer == nil {
+		return ctx
+	}
+
+	return rpc.WithCallObserver(ctx, observer)
+}
@@ -0,0 +1,68 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRPCObserver(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		if method == "eth_blockNumber" {
+			return "0x10", nil
+		}
+		return nil, errors.New("method not supported")
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	// no observer is set by default
+	_, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+
+	type observation struct {
+		method string
+		params []interface{}
+		err    error
+	}
+	var (
+		mx           sync.Mutex
+		observations []observation
+	)
+	jail.SetRPCObserver(func(method string, params []interface{}, duration time.Duration, err error) {
+		mx.Lock()
+		defer mx.Unlock()
+
+		require.True(t, duration > 0)
+		observations = append(observations, observation{method, params, err})
+	})
+
+	_, err = jail.sendRPCCall(nil, `[`+testBlockNumberRequest+`,{"jsonrpc":"2.0","id":2,"method":"eth_getBalance","params":["0xadaf150b905cf5e6a778e553e15a139b6618bbb7","latest"]}]`)
+	require.NoError(t, err)
+
+	mx.Lock()
+	require.Len(t, observations, 2)
+	require.Equal(t, "eth_blockNumber", observations[0].method)
+	require.Empty(t, observations[0].params)
+	require.NoError(t, observations[0].err)
+	require.Equal(t, "eth_getBalance", observations[1].method)
+	require.Equal(t, []interface{}{"0xadaf150b905cf5e6a778e553e15a139b6618bbb7", "latest"}, observations[1].params)
+	require.EqualError(t, observations[1].err, "method not supported")
+	mx.Unlock()
+
+	jail.SetRPCObserver(nil)
+	_, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+
+	mx.Lock()
+	require.Len(t, observations, 2)
+	mx.Unlock()
+}
@@ -0,0 +1,115 @@
+package jail
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	// ErrNoState is returned by Snapshot if a cell doesn't define _status_state.
+	ErrNoState = errors.New("_status_state is not set")
+	// ErrIncompatibleSnapshot is returned by Restore if a snapshot
+	// was taken from a cell running a different script.
+	ErrIncompatibleSnapshot = errors.New("snapshot is from an incompatible script version")
+)
+
+// cellSnapshot is a serialized state of a cell.
+type cellSnapshot struct {
+	Version string          `json:"version"`
+	State   json.RawMessage `json:"state"`
+}
+
+// Snapshot serializes the _status_state object of a cell, so that it
+// can be restored with Restore, e.g. after the process is restarted.
+// Scripts opt in by keeping their state in _status_state, which
+// must be serializable with JSON.stringify.
+func (j *Jail) Snapshot(chatID string) ([]byte, error) {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := cell.Run(`typeof _status_state === "undefined" ? undefined : JSON.stringify(_status_state)`)
+	if err != nil {
+		return nil, err
+	}
+	if value.IsUndefined() {
+		return nil, ErrNoState
+	}
+
+	return json.Marshal(cellSnapshot{
+		Version: cell.scriptVersion(),
+		State:   json.RawMessage(value.String()),
+	})
+}
+
+// Restore parses the script of a cell like Parse, or like ParseWithBundles
+// if bundles are given, and sets its _status_state to the state saved with
+// Snapshot. The script and the bundles must be the same as the ones the
+// snapshot was taken from, otherwise ErrIncompatibleSnapshot is returned
+// and the cell is not parsed.
+func (j *Jail) Restore(chatID, js string, snapshot []byte, bundles ...string) error {
+	var s cellSnapshot
+	if err := json.Unmarshal(snapshot, &s); err != nil {
+		return err
+	}
+
+	if s.Version != hashScript(js, bundles) {
+		return ErrIncompatibleSnapshot
+	}
+
+	opts := defaultParseOptions(js)
+	opts.Bundles = bundles
+	if _, err := j.bundleScripts(bundles); err != nil {
+		return err
+	}
+
+	if _, err := j.parseCell(chatID, opts, nil); err != nil {
+		return err
+	}
+
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return err
+	}
+
+	state, err := cell.Call("JSON.parse", nil, string(s.State))
+	if err != nil {
+		return err
+	}
+
+	return cell.Set("_status_state", state)
+}
+
+// hashScript returns a hash of the code of a cell and the names
+// of the bundles run before it. Bundles are hashed by name, so that
+// snapshots survive updates of the bundled libraries.
+func hashScript(code string, bundles []string) string {
+	hash := sha256.New()
+	for _, name := range bundles {
+		hash.Write([]byte(name)) //nolint: errcheck
+		hash.Write([]byte{0})    //nolint: errcheck
+	}
+	hash.Write([]byte(code)) //nolint: errcheck
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// setScriptHash sets the hash of the code run by the cell, see hashScript.
+func (c *Cell) setScriptHash(hash string) {
+	c.scriptMx.Lock()
+	defer c.scriptMx.Unlock()
+
+	c.scriptHash = hash
+}
+
+// scriptVersion returns the version of the code run by the cell,
+// which is the hash set with setScriptHash.
+func (c *Cell) scriptVersion() string {
+	c.scriptMx.Lock()
+	defer c.scriptMx.Unlock()
+
+	return c.scriptHash
+}
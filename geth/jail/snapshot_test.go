@@ -0,0 +1,64 @@
+package jail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotAndRestore(t *testing.T) {
+	code := `
+		var _status_state = {count: 0};
+		var _status_catalog = {
+			increment: function () { return ++_status_state.count; }
+		};
+		function call(pathStr, paramsStr) {
+			return _status_catalog.increment();
+		}
+	`
+
+	jail := New(nil)
+	require.NotContains(t, jail.Parse("cell1", code), "error")
+	require.Equal(t, `{"result": 1}`, jail.Call("cell1", `["increment"]`, `{}`))
+	require.Equal(t, `{"result": 2}`, jail.Call("cell1", `["increment"]`, `{}`))
+
+	snapshot, err := jail.Snapshot("cell1")
+	require.NoError(t, err)
+	jail.Stop()
+
+	// the state survives a restart
+	jail = New(nil)
+	defer jail.Stop()
+
+	require.NoError(t, jail.Restore("cell1", code, snapshot))
+	require.Equal(t, `{"result": 3}`, jail.Call("cell1", `["increment"]`, `{}`))
+
+	// restoring replaces an existing cell
+	require.NoError(t, jail.Restore("cell1", code, snapshot))
+	require.Equal(t, `{"result": 3}`, jail.Call("cell1", `["increment"]`, `{}`))
+
+	// snapshots of another script are rejected
+	require.Equal(t, ErrIncompatibleSnapshot, jail.Restore("cell2", code+";", snapshot))
+	_, err = jail.cell("cell2")
+	require.Error(t, err)
+
+	// as well as invalid ones
+	require.Error(t, jail.Restore("cell2", code, []byte("{")))
+
+	// snapshots of cells parsed with bundles are restored with the same bundles
+	jail.RegisterBundle("counter", `var step = 10;`)
+	require.NotContains(t, jail.ParseWithBundles("cell5", code, "counter"), "error")
+	snapshot, err = jail.Snapshot("cell5")
+	require.NoError(t, err)
+	require.Equal(t, ErrIncompatibleSnapshot, jail.Restore("cell5", code, snapshot))
+	require.NoError(t, jail.Restore("cell5", code, snapshot, "counter"))
+	require.Equal(t, "10", jail.Execute("cell5", `step`))
+
+	// cells without a state can't be snapshotted
+	require.NotContains(t, jail.Parse("cell3", `var _status_catalog = {};`), "error")
+	_, err = jail.Snapshot("cell3")
+	require.Equal(t, ErrNoState, err)
+
+	_, err = jail.Snapshot("cell4")
+	require.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package jail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultStatusFetchTimeout is a default timeout of statusFetch requests.
+	DefaultStatusFetchTimeout = 10 * time.Second
+
+	// maxStatusFetchBodySize limits the size of a response body read by statusFetch.
+	maxStatusFetchBodySize = 10 << 20
+)
+
+// ErrFetchDomainNotAllowed is returned when statusFetch requests a domain
+// that is not in the allowlist.
+var ErrFetchDomainNotAllowed = errors.New("domain is not allowed")
+
+// statusFetchOptions are options of statusFetch() JS call.
+type statusFetchOptions struct {
+	Method  string
+	Headers map[string]string
+	Body    string
+}
+
+// statusFetchResponse is a response of statusFetch() JS call.
+type statusFetchResponse struct {
+	Status int
+	Body   string
+}
+
+// SetFetchAllowlist sets domains which can be requested with statusFetch().
+// Subdomains of the given domains are allowed as well.
+// By default, no domains are allowed. The list is copied.
+func (j *Jail) SetFetchAllowlist(domains []string) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.fetchAllowlist = append([]string(nil), domains...)
+}
+
+// SetFetchTimeout sets a timeout of statusFetch() requests.
+func (j *Jail) SetFetchTimeout(timeout time.Duration) {
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	j.fetchTimeout = timeout
+}
+
+// statusFetch performs an HTTP request to the domain allowlisted for the cell.
+func (j *Jail) statusFetch(cell *Cell, rawURL string, opts statusFetchOptions) (*statusFetchResponse, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme '%s'", u.Scheme)
+	}
+
+	cfg := j.cellConfig(cell)
+	if !domainAllowed(cfg.FetchAllowlist, u.Hostname()) {
+		return nil, fmt.Errorf("%s: %s", ErrFetchDomainNotAllowed, u.Hostname())
+	}
+
+	timeout := cfg.FetchTimeout
+	if timeout == 0 {
+		timeout = DefaultStatusFetchTimeout
+	}
+
+	if opts.Method == "" {
+		opts.Method = "GET"
+	}
+
+	var body io.Reader
+	if opts.Body != "" {
+		body = strings.NewReader(opts.Body)
+	}
+
+	req, err := http.NewRequest(opts.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range opts.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := http.Client{
+		Timeout: timeout,
+		// Redirects may lead to domains outside of the allowlist.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !domainAllowed(cfg.FetchAllowlist, req.URL.Hostname()) {
+				return fmt.Errorf("%s: %s", ErrFetchDomainNotAllowed, req.URL.Hostname())
+			}
+
+			return nil
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxStatusFetchBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &statusFetchResponse{
+		Status: resp.StatusCode,
+		Body:   string(data),
+	}, nil
+}
+
+// domainAllowed returns true if host is one of the domains or their subdomain.
+func domainAllowed(domains []string, host string) bool {
+	host = strings.ToLower(host)
+
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
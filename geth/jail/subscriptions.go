@@ -0,0 +1,245 @@
+package jail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/robertkrimen/otto"
+)
+
+// JSON-RPC methods of subscriptions.
+const (
+	subscribeMethod    = "eth_subscribe"
+	unsubscribeMethod  = "eth_unsubscribe"
+	notificationMethod = "eth_subscription"
+)
+
+// subscriptionErrorCode is a JSON-RPC error code of failed
+// subscription requests.
+const subscriptionErrorCode = -32000
+
+var (
+	// ErrSubscribeRequiresCallback is returned when eth_subscribe is sent
+	// synchronously or in a batch, so that notifications can't be delivered.
+	ErrSubscribeRequiresCallback = errors.New("eth_subscribe must be sent alone with sendAsync and a callback")
+	// ErrSubscriptionNotFound is returned when a cell unsubscribes
+	// from a subscription it doesn't have.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// subscriptionNotification is a notification delivered to the callback
+// of eth_subscribe, the same as sent by the node.
+type subscriptionNotification struct {
+	Version string                         `json:"jsonrpc"`
+	Method  string                         `json:"method"`
+	Params  subscriptionNotificationParams `json:"params"`
+}
+
+type subscriptionNotificationParams struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// singleRequest returns a request of a raw JSON-RPC payload
+// if the payload is not a batch.
+func singleRequest(request string) (rpcRequest, bool) {
+	if isBatchRequest(request) {
+		return rpcRequest{}, false
+	}
+
+	requests, err := decodeRequests(request)
+	if err != nil || len(requests) != 1 {
+		return rpcRequest{}, false
+	}
+
+	return requests[0], true
+}
+
+// isSubscribeRequest returns true if a raw JSON-RPC payload is a single eth_subscribe request.
+func isSubscribeRequest(request string) bool {
+	req, ok := singleRequest(request)
+	return ok && req.Method == subscribeMethod
+}
+
+// callsMethod returns true if any request of a raw JSON-RPC payload calls a method.
+func callsMethod(request, method string) bool {
+	requests, err := decodeRequests(request)
+	if err != nil {
+		return false
+	}
+
+	for _, req := range requests {
+		if req.Method == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subscribe creates a subscription requested by a cell with eth_subscribe.
+// It returns a response with an ID of the subscription and a function
+// delivering notifications to the callback with callback(null, notification)
+// until the cell unsubscribes with eth_unsubscribe or is removed.
+// The function must be called if the subscription was created.
+// The request goes through the same policies as other requests of the cell.
+func (j *Jail) subscribe(cell *Cell, request string, callback otto.Value, high bool) (interface{}, func(), error) {
+	if response, ok, err := j.policyResponse(cell, request); ok || err != nil {
+		return response, nil, err
+	}
+
+	client := j.RPCClient()
+	if client == nil {
+		return nil, nil, ErrNoRPCClient
+	}
+
+	if err := j.rpcScheduler.acquire(context.Background(), high); err != nil {
+		return nil, nil, err
+	}
+	defer j.rpcScheduler.release()
+
+	req, _ := singleRequest(request)
+	args := make([]interface{}, len(req.Params))
+	for i, param := range req.Params {
+		args[i] = param
+	}
+
+	ctx, cancel := j.requestContext(context.Background(), cell, request)
+	defer cancel()
+
+	notifications := make(chan json.RawMessage)
+	sub, err := client.Subscribe(ctx, "eth", notifications, args...)
+	if err != nil {
+		code := subscriptionErrorCode
+		if rpcErr, ok := err.(gethrpc.Error); ok {
+			code = rpcErr.ErrorCode()
+		}
+		response, err := newErrorResponses(request, code, err)
+		return response, nil, err
+	}
+
+	id := cell.addSubscription(sub)
+	forward := func() {
+		cell.forwardNotifications(id, sub, notifications, callback)
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      decodeID(req.ID),
+		"result":  id,
+	}, forward, nil
+}
+
+// unsubscribeResponse handles eth_unsubscribe of subscriptions created
+// by a cell. It returns false if a raw JSON-RPC payload is not
+// an eth_unsubscribe request.
+func (j *Jail) unsubscribeResponse(cell *Cell, request string) (interface{}, bool) {
+	req, ok := singleRequest(request)
+	if !ok || req.Method != unsubscribeMethod || cell == nil {
+		return nil, false
+	}
+
+	var id string
+	if len(req.Params) > 0 {
+		json.Unmarshal(req.Params[0], &id) //nolint: errcheck
+	}
+
+	if !cell.unsubscribe(id) {
+		response, err := newErrorResponses(request, subscriptionErrorCode, ErrSubscriptionNotFound)
+		return response, err == nil
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      decodeID(req.ID),
+		"result":  true,
+	}, true
+}
+
+// decodeID decodes an ID of a request to be used in a response.
+func decodeID(raw json.RawMessage) interface{} {
+	var id interface{}
+	if err := json.Unmarshal(raw, &id); err != nil || id == nil {
+		return 0
+	}
+
+	return id
+}
+
+// addSubscription adds a subscription of the cell and returns its ID.
+func (c *Cell) addSubscription(sub *gethrpc.ClientSubscription) string {
+	c.subsMx.Lock()
+	defer c.subsMx.Unlock()
+
+	if c.subs == nil {
+		c.subs = make(map[string]*gethrpc.ClientSubscription)
+	}
+
+	c.lastSubID++
+	id := fmt.Sprintf("0x%x", c.lastSubID)
+	c.subs[id] = sub
+
+	return id
+}
+
+// unsubscribe cancels a subscription of the cell.
+// It returns false if the cell has no such subscription.
+func (c *Cell) unsubscribe(id string) bool {
+	c.subsMx.Lock()
+	sub, ok := c.subs[id]
+	delete(c.subs, id)
+	c.subsMx.Unlock()
+
+	if ok {
+		sub.Unsubscribe()
+	}
+
+	return ok
+}
+
+// forwardNotifications delivers notifications of a subscription to the callback
+// until the subscription is cancelled or the cell is stopped.
+func (c *Cell) forwardNotifications(id string, sub *gethrpc.ClientSubscription, notifications <-chan json.RawMessage, callback otto.Value) {
+	defer c.unsubscribe(id)
+
+	for {
+		select {
+		case result := <-notifications:
+			notification := subscriptionNotification{
+				Version: "2.0",
+				Method:  notificationMethod,
+				Params: subscriptionNotificationParams{
+					Subscription: id,
+					Result:       result,
+				},
+			}
+
+			value, err := toJSValue(notification)
+			if err != nil {
+				continue
+			}
+			c.CallAsync(callback, nil, value)
+		case <-sub.Err():
+			return
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// toJSValue converts a value to a plain JS-compatible one by
+// marshalling it to JSON and unmarshalling it back.
+func toJSValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+
+	return value, err
+}
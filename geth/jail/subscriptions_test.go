@@ -0,0 +1,148 @@
+package jail
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSubscriptionService notifies subscribers of newHeads with increasing numbers.
+type TestSubscriptionService struct {
+	unsubscribed chan struct{}
+}
+
+func (s *TestSubscriptionService) NewHeads(ctx context.Context) (*gethrpc.Subscription, error) {
+	notifier, ok := gethrpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, gethrpc.ErrNotificationsUnsupported
+	}
+
+	sub := notifier.CreateSubscription()
+	go func() {
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+
+		for n := 1; ; n++ {
+			select {
+			case <-ticker.C:
+				notifier.Notify(sub.ID, n) //nolint: errcheck
+			case <-sub.Err():
+				s.unsubscribed <- struct{}{}
+				return
+			}
+		}
+	}()
+
+	return sub, nil
+}
+
+func TestSubscriptions(t *testing.T) {
+	service := &TestSubscriptionService{unsubscribed: make(chan struct{}, 1)}
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+
+	client, err := rpc.NewClient(gethrpc.DialInProc(server), params.UpstreamRPCConfig{})
+	require.NoError(t, err)
+
+	jail := New(&testRPCClientProvider{client})
+	cell, err := jail.createAndInitCell("cell1")
+	require.NoError(t, err)
+
+	_, err = cell.Run(`
+		var subscription = null, heads = [];
+		jeth.sendAsync({jsonrpc: "2.0", id: 1, method: "eth_subscribe", params: ["newHeads"]}, function(err, result) {
+			if (result.method === "eth_subscription") {
+				if (result.params.subscription !== subscription) {
+					throw new Error("unexpected subscription " + result.params.subscription);
+				}
+				heads.push(result.params.result);
+			} else {
+				subscription = result.result;
+			}
+		});
+	`)
+	require.NoError(t, err)
+
+	waitForHeads := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			value, err := cell.Run(`heads.length`)
+			require.NoError(t, err)
+			length, err := value.ToInteger()
+			require.NoError(t, err)
+			if int(length) >= n {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("got %d notifications, expected %d", length, n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForHeads(3)
+
+	value, err := cell.Run(`subscription`)
+	require.NoError(t, err)
+	require.Equal(t, "0x1", value.String())
+	value, err = cell.Run(`heads.slice(0, 2).join(",")`)
+	require.NoError(t, err)
+	require.Equal(t, "1,2", value.String())
+
+	// eth_unsubscribe cancels the subscription
+	value, err = cell.Run(`jeth.send({jsonrpc: "2.0", id: 2, method: "eth_unsubscribe", params: [subscription]}).result`)
+	require.NoError(t, err)
+	require.Equal(t, "true", value.String())
+	select {
+	case <-service.unsubscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription was not cancelled")
+	}
+
+	value, err = cell.Run(`jeth.send({jsonrpc: "2.0", id: 3, method: "eth_unsubscribe", params: [subscription]}).error.message`)
+	require.NoError(t, err)
+	require.Equal(t, ErrSubscriptionNotFound.Error(), value.String())
+
+	// eth_subscribe can't be sent synchronously
+	value, err = cell.Run(`jeth.send({jsonrpc: "2.0", id: 4, method: "eth_subscribe", params: ["newHeads"]}).error.message`)
+	require.NoError(t, err)
+	require.Equal(t, ErrSubscribeRequiresCallback.Error(), value.String())
+
+	// eth_subscribe goes through the same policies as other requests
+	require.NoError(t, jail.SetCellEnabled("cell1", false))
+	_, err = cell.Run(`var subscribeError = null;
+		jeth.sendAsync({jsonrpc: "2.0", id: 6, method: "eth_subscribe", params: ["newHeads"]}, function(err, result) {
+			subscribeError = err.message;
+		})`)
+	require.NoError(t, err)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		value, err = cell.Run(`subscribeError`)
+		require.NoError(t, err)
+		if value.String() != "null" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, ErrCellDisabled.Error(), value.String())
+	require.NoError(t, jail.SetCellEnabled("cell1", true))
+
+	// removing the cell cancels its subscriptions
+	_, err = cell.Run(`heads = []; jeth.sendAsync({jsonrpc: "2.0", id: 5, method: "eth_subscribe", params: ["newHeads"]}, function(err, result) {
+		if (result.method === "eth_subscription") heads.push(result.params.result);
+	})`)
+	require.NoError(t, err)
+	waitForHeads(1)
+
+	require.NoError(t, jail.RemoveCell("cell1"))
+	select {
+	case <-service.unsubscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription was not cancelled")
+	}
+}
@@ -0,0 +1,104 @@
+package jail
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// SetHTTPProxy configures the RPC client to send requests to the upstream
+// through an HTTP proxy. An empty proxyURL removes the proxy.
+// Only HTTP upstream endpoints are supported.
+func (j *Jail) SetHTTPProxy(proxyURL string) error {
+	var proxy *url.URL
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %v", err)
+		}
+		proxy = u
+	}
+
+	j.settingsMx.Lock()
+	j.httpProxy = proxy
+	j.settingsMx.Unlock()
+
+	return j.applyHTTPTransport()
+}
+
+// SetHTTPTransport configures the pool of idle connections of the RPC client
+// to the upstream. maxIdle limits idle connections across all hosts and
+// maxIdlePerHost limits them per host. Zero values mean default limits.
+// Idle connections are closed after idleTimeout, zero means no timeout.
+// Only HTTP upstream endpoints are supported.
+func (j *Jail) SetHTTPTransport(maxIdle, maxIdlePerHost int, idleTimeout time.Duration) error {
+	if maxIdle < 0 || maxIdlePerHost < 0 || idleTimeout < 0 {
+		return fmt.Errorf("invalid transport settings: negative value")
+	}
+
+	j.settingsMx.Lock()
+	j.httpPool = &httpPoolSettings{
+		maxIdle:        maxIdle,
+		maxIdlePerHost: maxIdlePerHost,
+		idleTimeout:    idleTimeout,
+	}
+	j.settingsMx.Unlock()
+
+	return j.applyHTTPTransport()
+}
+
+// applyHTTPTransport replaces the transport of the RPC client
+// with the one built from the current settings.
+func (j *Jail) applyHTTPTransport() error {
+	client := j.RPCClient()
+	if client == nil {
+		return ErrNoRPCClient
+	}
+
+	return client.SetHTTPTransport(j.httpTransport())
+}
+
+// configureClient applies the jail's client settings to a new client.
+func (j *Jail) configureClient(client *rpc.Client) error {
+	j.settingsMx.RLock()
+	customTransport := j.httpProxy != nil || j.httpPool != nil
+	j.settingsMx.RUnlock()
+
+	if !customTransport {
+		return nil
+	}
+
+	return client.SetHTTPTransport(j.httpTransport())
+}
+
+// httpTransport builds http.Transport from the current settings.
+func (j *Jail) httpTransport() *http.Transport {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+
+	if j.httpProxy != nil {
+		transport.Proxy = http.ProxyURL(j.httpProxy)
+	}
+
+	if j.httpPool != nil {
+		transport.MaxIdleConns = j.httpPool.maxIdle
+		transport.MaxIdleConnsPerHost = j.httpPool.maxIdlePerHost
+		transport.IdleConnTimeout = j.httpPool.idleTimeout
+	}
+
+	return transport
+}
+
+// httpPoolSettings defines the pool of idle HTTP connections.
+type httpPoolSettings struct {
+	maxIdle        int
+	maxIdlePerHost int
+	idleTimeout    time.Duration
+}
@@ -0,0 +1,170 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProxy starts an HTTP proxy counting proxied requests.
+func newTestProxy(proxied *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(proxied, 1)
+
+		req, err := http.NewRequest(r.Method, r.URL.String(), r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		req.Header = r.Header
+
+		resp, err := new(http.Transport).RoundTrip(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close() //nolint: errcheck
+
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body) //nolint: errcheck
+	}))
+}
+
+// newTestUpstreamClient returns rpc.Client with upstream enabled
+// responding to all requests with result.
+func newTestUpstreamClient(t *testing.T, result string) (*rpc.Client, func()) {
+	upstream := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return result, nil
+	})
+
+	local, err := gethrpc.Dial(upstream.URL)
+	require.NoError(t, err)
+	client, err := rpc.NewClient(local, params.UpstreamRPCConfig{Enabled: true, URL: upstream.URL})
+	require.NoError(t, err)
+
+	return client, upstream.Close
+}
+
+const testBlockNumberRequest = `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+
+func TestSetHTTPProxy(t *testing.T) {
+	client, closeUpstream := newTestUpstreamClient(t, "0x10")
+	defer closeUpstream()
+
+	var proxied int32
+	proxy := newTestProxy(&proxied)
+	defer proxy.Close()
+
+	jail := New(&testRPCClientProvider{client})
+
+	// jail without a client
+	require.Equal(t, ErrNoRPCClient, New(nil).SetHTTPProxy(proxy.URL))
+
+	require.NoError(t, jail.SetHTTPProxy(proxy.URL))
+
+	response, err := jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, "0x10", response.(map[string]interface{})["result"])
+	require.Equal(t, int32(1), atomic.LoadInt32(&proxied))
+
+	// removing the proxy
+	require.NoError(t, jail.SetHTTPProxy(""))
+	_, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&proxied))
+}
+
+func TestClientSettingsAfterClientChange(t *testing.T) {
+	client1, closeUpstream1 := newTestUpstreamClient(t, "0x1")
+	defer closeUpstream1()
+	client2, closeUpstream2 := newTestUpstreamClient(t, "0x2")
+	defer closeUpstream2()
+
+	var proxied int32
+	proxy := newTestProxy(&proxied)
+	defer proxy.Close()
+
+	provider := &testRPCClientProvider{client1}
+	jail := New(provider)
+	require.NoError(t, jail.SetHTTPProxy(proxy.URL))
+
+	response, err := jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, "0x1", response.(map[string]interface{})["result"])
+	require.Equal(t, int32(1), atomic.LoadInt32(&proxied))
+
+	// the endpoint changes, e.g. the node is restarted with another network
+	provider.rpcClient = client2
+	response, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, "0x2", response.(map[string]interface{})["result"])
+	require.Equal(t, int32(2), atomic.LoadInt32(&proxied))
+
+	// settings are applied again after invalidation
+	require.NoError(t, client2.SetHTTPTransport(new(http.Transport)))
+	jail.InvalidateClient()
+	_, err = jail.sendRPCCall(nil, testBlockNumberRequest)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&proxied))
+}
+
+func TestSetHTTPTransportReusesConnections(t *testing.T) {
+	var dials, requests int32
+	upstream := httptest.NewUnstartedServer(newTestRPCHTTPHandler(func(method string, params []json.RawMessage) (interface{}, error) {
+		atomic.AddInt32(&requests, 1)
+		return "0x10", nil
+	}))
+	upstream.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&dials, 1)
+		}
+	}
+	upstream.Start()
+	defer upstream.Close()
+
+	// the local node is a different server, so that only upstream connections are counted
+	localServer := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return nil, errors.New("unexpected local request")
+	})
+	defer localServer.Close()
+
+	local, err := gethrpc.Dial(localServer.URL)
+	require.NoError(t, err)
+	client, err := rpc.NewClient(local, params.UpstreamRPCConfig{Enabled: true, URL: upstream.URL})
+	require.NoError(t, err)
+
+	jail := New(&testRPCClientProvider{client})
+	require.Error(t, jail.SetHTTPTransport(-1, 0, 0))
+
+	send := func(n int) {
+		for i := 0; i < n; i++ {
+			response, err := jail.sendRPCCall(nil, testBlockNumberRequest)
+			require.NoError(t, err)
+			require.Equal(t, "0x10", response.(map[string]interface{})["result"])
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	// idle connections are reused
+	require.NoError(t, jail.SetHTTPTransport(10, 10, time.Minute))
+	send(5)
+	require.Equal(t, int32(5), atomic.LoadInt32(&requests))
+	require.Equal(t, int32(1), atomic.LoadInt32(&dials))
+
+	// and closed once they are idle for longer than the timeout
+	require.NoError(t, jail.SetHTTPTransport(10, 10, time.Millisecond))
+	send(3)
+	require.Equal(t, int32(8), atomic.LoadInt32(&requests))
+	require.Equal(t, int32(4), atomic.LoadInt32(&dials))
+}
@@ -0,0 +1,32 @@
+package jail
+
+// unitsCode defines toWei(val, unit) and fromWei(val, unit) converting
+// between wei and other units, e.g. gwei and ether, which is the default.
+// They return strings, or Bignumber if val is Bignumber, like web3.toWei.
+// Functions defined by base JS are kept.
+const unitsCode = `
+	var statusUnits = {
+		wei: "1",
+		kwei: "1000",
+		mwei: "1000000",
+		gwei: "1000000000",
+		szabo: "1000000000000",
+		finney: "1000000000000000",
+		ether: "1000000000000000000"
+	};
+	function statusUnit(unit) {
+		var name = String(unit || "ether").toLowerCase();
+		if (!Object.prototype.hasOwnProperty.call(statusUnits, name)) {
+			throw new Error("unknown unit: " + unit);
+		}
+		return new Bignumber(statusUnits[name], 10);
+	}
+	var toWei = typeof toWei === "function" ? toWei : function(val, unit) {
+		var wei = new Bignumber(val).times(statusUnit(unit));
+		return val instanceof Bignumber ? wei : wei.toString(10);
+	};
+	var fromWei = typeof fromWei === "function" ? fromWei : function(val, unit) {
+		var value = new Bignumber(val).dividedBy(statusUnit(unit));
+		return val instanceof Bignumber ? value : value.toString(10);
+	};
+`
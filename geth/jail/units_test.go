@@ -0,0 +1,31 @@
+package jail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnits(t *testing.T) {
+	jail := New(nil)
+	defer jail.Stop()
+
+	response := jail.Parse("cell1", `var _status_catalog = {
+		ether: toWei('1', 'ether'),
+		gwei: toWei(2, 'gwei'),
+		defaultUnit: toWei('0.5'),
+		fromWei: fromWei('1500000000000000000', 'ether'),
+		fromGwei: fromWei(toWei('3', 'gwei'), 'GWEI'),
+		bignumber: fromWei(bn('1000'), 'wei') instanceof Bignumber
+	};`)
+	require.Equal(t, `{"result": {"bignumber":true,"defaultUnit":"500000000000000000","ether":"1000000000000000000","fromGwei":"3","fromWei":"1.5","gwei":"2000000000"}}`, response)
+
+	require.Equal(t, `{"error":"Error: unknown unit: wie"}`, jail.Execute("cell1", `toWei('1', 'wie')`))
+
+	// functions defined by base JS are kept
+	jail = NewWithBaseJS(nil, `function toWei(val, unit) { return "custom"; }`)
+	defer jail.Stop()
+
+	response = jail.Parse("cell1", `var _status_catalog = {toWei: toWei('1', 'ether'), fromWei: fromWei('1', 'wei')};`)
+	require.Equal(t, `{"result": {"fromWei":"1","toWei":"custom"}}`, response)
+}
@@ -0,0 +1,91 @@
+package jail
+
+import (
+	"time"
+
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+const (
+	// minUpstreamDialBackoff is a delay before dialing the upstream RPC
+	// provider again after the first failure. It doubles with each failure.
+	minUpstreamDialBackoff = 100 * time.Millisecond
+
+	// maxUpstreamDialBackoff is the maximum delay between dials of the upstream RPC provider.
+	maxUpstreamDialBackoff = 30 * time.Second
+)
+
+// SetUpstreamRPC makes cells send requests to an external RPC provider with
+// a given URL, e.g. Infura, instead of the node. Like with the upstream of
+// the node, only methods supported by such providers are sent to it, while
+// the rest, like account methods, still go to the node. The client connected
+// to the URL is cached and closed when the URL or the node's client changes.
+// If connecting fails, e.g. due to a transient network failure, requests
+// fail with ErrNoRPCClient and connecting is retried with a later request,
+// backing off exponentially. An empty URL clears the upstream.
+func (j *Jail) SetUpstreamRPC(url string) {
+	j.clientMx.Lock()
+	stale := j.upstreamClient
+	j.upstreamURL = url
+	j.upstreamClient = nil
+	j.upstreamBackoff = 0
+	j.upstreamDialAt = time.Time{}
+	j.clientMx.Unlock()
+
+	if stale != nil {
+		stale.Close()
+	}
+}
+
+// dialUpstreamRPC returns a client routing requests of client to the upstream
+// with a given URL. It returns nil if dialing fails or is backing off.
+func (j *Jail) dialUpstreamRPC(client *rpc.Client, url string) *rpc.Client {
+	j.clientMx.Lock()
+	backingOff := j.now().Before(j.upstreamDialAt)
+	j.clientMx.Unlock()
+	if backingOff {
+		return nil
+	}
+
+	upstream, err := client.WithUpstream(url)
+
+	j.clientMx.Lock()
+	defer j.clientMx.Unlock()
+
+	// The upstream or the node's client changed while dialing.
+	if url != j.upstreamURL || client != j.client {
+		if upstream != nil {
+			upstream.Close()
+		}
+		return nil
+	}
+
+	if err != nil {
+		log.Warn("failed to connect to upstream RPC", "err", err)
+		j.upstreamBackoff *= 2
+		if j.upstreamBackoff < minUpstreamDialBackoff {
+			j.upstreamBackoff = minUpstreamDialBackoff
+		} else if j.upstreamBackoff > maxUpstreamDialBackoff {
+			j.upstreamBackoff = maxUpstreamDialBackoff
+		}
+		j.upstreamDialAt = j.now().Add(j.upstreamBackoff)
+		return nil
+	}
+
+	// Another request dialed in the meantime.
+	if j.upstreamClient != nil {
+		upstream.Close()
+		return j.upstreamClient
+	}
+
+	// The upstream is the one sending requests over HTTP.
+	if err := j.configureClient(upstream); err != nil {
+		log.Warn("failed to configure RPC client", "err", err)
+	}
+	j.upstreamClient = upstream
+	j.upstreamBackoff = 0
+	j.upstreamDialAt = time.Time{}
+
+	return upstream
+}
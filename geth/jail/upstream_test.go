@@ -0,0 +1,88 @@
+package jail
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWSService is an upstream RPC service served over WebSocket.
+type TestWSService struct{}
+
+func (TestWSService) BlockNumber() string {
+	return "ws"
+}
+
+func TestSetUpstreamRPC(t *testing.T) {
+	local := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return "local", nil
+	})
+	defer local.Close()
+
+	upstream := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return "upstream", nil
+	})
+	defer upstream.Close()
+
+	provider, err := newTestRPCClientProvider(local.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	send := func(method string) string {
+		response, err := jail.SendRaw(`{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[]}`)
+		require.NoError(t, err)
+		return response
+	}
+
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"local"}`, send("eth_blockNumber"))
+
+	// methods supported by providers are sent to the upstream
+	jail.SetUpstreamRPC(upstream.URL)
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"upstream"}`, send("eth_blockNumber"))
+	require.True(t, jail.RPCClient() == jail.RPCClient(), "upstream client is not cached")
+
+	// while account methods go to the node
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"local"}`, send("eth_accounts"))
+
+	// the upstream client is closed when the upstream changes
+	wsServer := gethrpc.NewServer()
+	require.NoError(t, wsServer.RegisterName("eth", TestWSService{}))
+	wsUpstream := httptest.NewServer(wsServer.WebsocketHandler([]string{"*"}))
+	defer wsUpstream.Close()
+
+	jail.SetUpstreamRPC("ws" + strings.TrimPrefix(wsUpstream.URL, "http"))
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"ws"}`, send("eth_blockNumber"))
+
+	client := jail.RPCClient()
+	jail.SetUpstreamRPC(upstream.URL)
+	var result string
+	require.Equal(t, gethrpc.ErrClientQuit, client.Call(&result, "eth_blockNumber"))
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"upstream"}`, send("eth_blockNumber"))
+
+	// requests fail until connecting to the upstream succeeds
+	now := time.Now()
+	jail.now = func() time.Time { return now }
+	jail.SetUpstreamRPC("ws://127.0.0.1:1")
+	_, err = jail.SendRaw(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	require.Equal(t, ErrNoRPCClient, err)
+
+	// and connecting is not retried before the backoff passes
+	jail.clientMx.Lock()
+	jail.upstreamURL = upstream.URL
+	jail.clientMx.Unlock()
+	_, err = jail.SendRaw(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	require.Equal(t, ErrNoRPCClient, err)
+
+	now = now.Add(minUpstreamDialBackoff)
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"upstream"}`, send("eth_blockNumber"))
+
+	// the upstream is cleared with an empty URL
+	jail.SetUpstreamRPC("")
+	require.Equal(t, `{"id":1,"jsonrpc":"2.0","result":"local"}`, send("eth_blockNumber"))
+}
@@ -0,0 +1,120 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// ErrWatchOnlyAccount is returned in an error response when a signing
+// request is sent on behalf of a watch-only account.
+var ErrWatchOnlyAccount = errors.New("account is watch-only")
+
+// watchOnlyErrorCode is a JSON-RPC error code of ErrWatchOnlyAccount.
+const watchOnlyErrorCode = -32000
+
+// ImportWatchOnly adds an address to the watch-only registry.
+// A watch-only address can be tracked, but it's not backed by a key,
+// so signing requests on its behalf are rejected.
+func (j *Jail) ImportWatchOnly(address string) error {
+	if !gethcommon.IsHexAddress(address) {
+		return fmt.Errorf("invalid address: %s", address)
+	}
+
+	j.settingsMx.Lock()
+	defer j.settingsMx.Unlock()
+
+	if j.watchOnly == nil {
+		j.watchOnly = make(map[gethcommon.Address]struct{})
+	}
+	j.watchOnly[gethcommon.HexToAddress(address)] = struct{}{}
+
+	return nil
+}
+
+// ListWatchOnly returns sorted watch-only addresses.
+func (j *Jail) ListWatchOnly() []string {
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	addresses := make([]string, 0, len(j.watchOnly))
+	for address := range j.watchOnly {
+		addresses = append(addresses, address.Hex())
+	}
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+func (j *Jail) isWatchOnly(address string) bool {
+	if !gethcommon.IsHexAddress(address) {
+		return false
+	}
+
+	j.settingsMx.RLock()
+	defer j.settingsMx.RUnlock()
+
+	_, ok := j.watchOnly[gethcommon.HexToAddress(address)]
+	return ok
+}
+
+// checkWatchOnly returns an error if any request of a raw JSON-RPC
+// payload is a signing request on behalf of a watch-only account.
+func (j *Jail) checkWatchOnly(request string) error {
+	requests, err := decodeRequests(request)
+	if err != nil {
+		// Malformed requests are handled by the RPC client.
+		return nil
+	}
+
+	for _, req := range requests {
+		signer := signerAddress(req)
+		if signer != "" && j.isWatchOnly(signer) {
+			return fmt.Errorf("%s: %s", ErrWatchOnlyAccount, signer)
+		}
+	}
+
+	return nil
+}
+
+// signerAddress returns an address of an account which is requested
+// to sign something or an empty string if it's not a signing request.
+func signerAddress(req rpcRequest) string {
+	var (
+		address string
+		index   int
+	)
+
+	switch req.Method {
+	case "eth_sendTransaction", "eth_signTransaction":
+		if len(req.Params) == 0 {
+			return ""
+		}
+
+		var tx struct {
+			From string `json:"from"`
+		}
+		if err := json.Unmarshal(req.Params[0], &tx); err != nil {
+			return ""
+		}
+		return tx.From
+	case "eth_sign":
+		index = 0
+	case "personal_sign":
+		index = 1
+	default:
+		return ""
+	}
+
+	if len(req.Params) <= index {
+		return ""
+	}
+	if err := json.Unmarshal(req.Params[index], &address); err != nil {
+		return ""
+	}
+
+	return address
+}
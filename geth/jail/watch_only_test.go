@@ -0,0 +1,48 @@
+package jail
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchOnlyAccounts(t *testing.T) {
+	ts := newTestRPCServer(func(method string, params []json.RawMessage) (interface{}, error) {
+		return "0x", nil
+	})
+	defer ts.Close()
+
+	provider, err := newTestRPCClientProvider(ts.URL)
+	require.NoError(t, err)
+
+	jail := New(provider)
+
+	require.EqualError(t, jail.ImportWatchOnly("0xinvalid"), "invalid address: 0xinvalid")
+
+	address := "0xadaf150b905cf5e6a778e553e15a139b6618bbb7"
+	require.NoError(t, jail.ImportWatchOnly(address))
+	require.Equal(t, []string{"0xAdAf150b905Cf5E6A778E553E15A139B6618BbB7"}, jail.ListWatchOnly())
+
+	// signing requests are rejected with an error response
+	message := ErrWatchOnlyAccount.Error() + ": " + address
+	response, err := jail.sendRPCCall(nil, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[{"from":"`+address+`"}]}`)
+	require.NoError(t, err)
+	require.Equal(t, message, response.(map[string]interface{})["error"].(map[string]interface{})["message"])
+
+	response, err = jail.sendRPCCall(nil, `[{"jsonrpc":"2.0","id":1,"method":"personal_sign","params":["0xdeadbeaf","`+address+`"]}]`)
+	require.NoError(t, err)
+	require.Equal(t, message, response.([]interface{})[0].(map[string]interface{})["error"].(map[string]interface{})["message"])
+
+	// even in the dry-run mode
+	jail.Parse("cell1", `var _status_catalog = {};`)
+	jail.SetDryRun(true)
+	value := jail.Execute("cell1", `jeth.send({jsonrpc: "2.0", id: 2, method: "eth_sendTransaction", params: [{from: "`+address+`"}]}).error.message`)
+	require.Equal(t, message, value)
+	require.Empty(t, jail.RecordedCalls("cell1"))
+	jail.SetDryRun(false)
+
+	// other requests are allowed
+	_, err = jail.sendRPCCall(nil, `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["`+address+`","latest"]}`)
+	require.NoError(t, err)
+}
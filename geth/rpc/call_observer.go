@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"context"
+	"time"
+)
+
+// CallObserver is notified about a JSON-RPC request performed with CallRawContext.
+// err is nil if the request succeeded.
+type CallObserver func(method string, params []interface{}, duration time.Duration, err error)
+
+type callObserverKey struct{}
+
+// WithCallObserver returns a context which makes CallRawContext notify
+// the observer after each request. Requests of a batch are reported
+// one by one with their own durations.
+func WithCallObserver(ctx context.Context, observer CallObserver) context.Context {
+	return context.WithValue(ctx, callObserverKey{}, observer)
+}
+
+// callObserverFromContext returns the observer of a context or nil.
+func callObserverFromContext(ctx context.Context) CallObserver {
+	observer, _ := ctx.Value(callObserverKey{}).(CallObserver)
+	return observer
+}
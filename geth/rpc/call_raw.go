@@ -1,8 +1,13 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
 
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/status-im/status-go/geth/log"
@@ -11,8 +16,14 @@ import (
 const (
 	jsonrpcVersion        = "2.0"
 	errInvalidMessageCode = -32700 // from go-ethereum/rpc/errors.go
+	errTransportCode      = -32000 // server error, see http://www.jsonrpc.org/specification#error_object
 )
 
+// transportErrorData marks error responses caused by failures to reach
+// a remote node, like network errors, so that callers can tell them apart
+// from errors returned by the node or local handlers.
+var transportErrorData = map[string]interface{}{"transport": true}
+
 // for JSON-RPC responses obtained via CallRaw(), we have no way
 // to know ID field from actual response. web3.js (primary and
 // only user of CallRaw()) will validate response by checking
@@ -28,6 +39,12 @@ func (c *Client) CallRaw(body string) string {
 	return c.callRawContext(ctx, json.RawMessage(body))
 }
 
+// CallRawContext works like CallRaw, but the call can be cancelled
+// or time out with the given context.
+func (c *Client) CallRawContext(ctx context.Context, body string) string {
+	return c.callRawContext(ctx, json.RawMessage(body))
+}
+
 // jsonrpcMessage represents JSON-RPC message
 type jsonrpcMessage struct {
 	Version string          `json:"jsonrpc"`
@@ -99,6 +116,16 @@ func (c *Client) callBatchMethods(ctx context.Context, msgs json.RawMessage) str
 		responses[i] = json.RawMessage(resp)
 	}
 
+	// Callers match responses to requests by ID, so make sure
+	// that each response carries the ID of its request.
+	for i := range requests {
+		id := requestID(requests[i])
+		if err := checkResponseID(responses[i], id); err != nil {
+			log.Error("Invalid batch response:", err)
+			responses[i] = json.RawMessage(newErrorResponse(errInvalidMessageCode, err, id))
+		}
+	}
+
 	data, err := json.Marshal(responses)
 	if err != nil {
 		log.Error("Failed to marshal batch responses:", err)
@@ -118,8 +145,17 @@ func (c *Client) callSingleMethod(ctx context.Context, msg json.RawMessage) stri
 
 	// route and execute
 	var result json.RawMessage
+	start := time.Now()
 	err = c.CallContext(ctx, &result, method, params...)
 
+	if observer := callObserverFromContext(ctx); observer != nil {
+		observedErr := err
+		if err == gethrpc.ErrNoResult {
+			observedErr = nil
+		}
+		observer(method, params, time.Since(start), observedErr)
+	}
+
 	// as we have to return original JSON, we have to
 	// analyze returned error and reconstruct original
 	// JSON error response.
@@ -128,6 +164,10 @@ func (c *Client) callSingleMethod(ctx context.Context, msg json.RawMessage) stri
 			return newErrorResponse(er.ErrorCode(), err, id)
 		}
 
+		if isTransportError(err) {
+			return newTransportErrorResponse(err, id)
+		}
+
 		return newErrorResponse(errInvalidMessageCode, err, id)
 	}
 
@@ -135,6 +175,37 @@ func (c *Client) callSingleMethod(ctx context.Context, msg json.RawMessage) stri
 	return newSuccessResponse(result, id)
 }
 
+// requestID returns an ID of a JSON-RPC request or defaultMsgID if the request has none.
+func requestID(msg json.RawMessage) json.RawMessage {
+	var req jsonrpcMessage
+	if err := json.Unmarshal(msg, &req); err != nil || req.ID == nil {
+		return defaultMsgID
+	}
+
+	return req.ID
+}
+
+// checkResponseID returns an error if a JSON-RPC response doesn't carry a given ID.
+func checkResponseID(response, id json.RawMessage) error {
+	var resp jsonrpcMessage
+	if err := json.Unmarshal(response, &resp); err != nil {
+		return err
+	}
+
+	var expected, got bytes.Buffer
+	if err := json.Compact(&expected, id); err != nil {
+		return err
+	}
+	if err := json.Compact(&got, resp.ID); err != nil {
+		return fmt.Errorf("response to request %s has invalid id: %v", expected.String(), err)
+	}
+	if !bytes.Equal(expected.Bytes(), got.Bytes()) {
+		return fmt.Errorf("response to request %s has id %s", expected.String(), got.String())
+	}
+
+	return nil
+}
+
 // methodAndParamsFromBody extracts Method and Params of
 // JSON-RPC body into values ready to use with ethereum-go's
 // RPC client Call() function. A lot of empty interface usage is
@@ -199,6 +270,41 @@ func newErrorResponse(code int, err error, id json.RawMessage) string {
 	return string(data)
 }
 
+// newTransportErrorResponse returns an error response for a failure
+// to reach a remote node, e.g. a connection error.
+func newTransportErrorResponse(err error, id json.RawMessage) string {
+	if id == nil {
+		id = defaultMsgID
+	}
+
+	errMsg := &jsonrpcErrorResponse{
+		jsonrpcMessage: jsonrpcMessage{
+			ID:      id,
+			Version: jsonrpcVersion,
+		},
+		Error: jsonError{
+			Code:    errTransportCode,
+			Message: err.Error(),
+			Data:    transportErrorData,
+		},
+	}
+
+	data, _ := json.Marshal(errMsg)
+	return string(data)
+}
+
+// isTransportError returns true if err is a failure to reach a remote
+// node, like a dial or connection error, rather than an error returned
+// by a handler.
+func isTransportError(err error) bool {
+	switch err.(type) {
+	case net.Error, *url.Error:
+		return true
+	}
+
+	return false
+}
+
 // isBatch returns true when the first non-whitespace characters is '['
 // code from go-ethereum's rpc client (rpc/client.go)
 func isBatch(msg json.RawMessage) bool {
@@ -1,10 +1,14 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/status-im/status-go/geth/params"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,6 +41,66 @@ func TestNewErrorResponse(t *testing.T) {
 	require.Equal(t, expected, got)
 }
 
+func TestCallRawErrorMessage(t *testing.T) {
+	client, err := NewClient(nil, params.UpstreamRPCConfig{})
+	require.NoError(t, err)
+
+	client.RegisterHandler("eth_test", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("some error text")
+	})
+
+	got := client.CallRaw(`{"jsonrpc":"2.0","id":1,"method":"eth_test","params":[]}`)
+
+	var response struct {
+		Error map[string]interface{} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(got), &response))
+	require.Equal(t, "some error text", response.Error["message"])
+	require.Equal(t, float64(errInvalidMessageCode), response.Error["code"])
+	require.Len(t, response.Error, 2)
+}
+
+// testRPCError is an error returned by the node.
+type testRPCError struct{}
+
+func (testRPCError) Error() string  { return "execution reverted" }
+func (testRPCError) ErrorCode() int { return -32015 }
+
+func TestCallRawTransportError(t *testing.T) {
+	// the upstream node is unreachable
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	client, err := NewClient(nil, params.UpstreamRPCConfig{Enabled: true, URL: ts.URL})
+	require.NoError(t, err)
+
+	client.RegisterHandler("eth_refused", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("dial tcp 127.0.0.1:8545: connection refused")
+	})
+	client.RegisterHandler("eth_revert", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, testRPCError{}
+	})
+
+	// connection errors are marked as transport failures
+	got := client.CallRaw(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	var response struct {
+		Error map[string]interface{} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(got), &response))
+	require.Equal(t, float64(errTransportCode), response.Error["code"])
+	require.Equal(t, map[string]interface{}{"transport": true}, response.Error["data"])
+
+	// errors of local handlers are not, whatever they say
+	got = client.CallRaw(`{"jsonrpc":"2.0","id":2,"method":"eth_refused","params":[]}`)
+	expected := `{"jsonrpc":"2.0","id":2,"error":{"code":-32700,"message":"dial tcp 127.0.0.1:8545: connection refused"}}`
+	require.Equal(t, expected, got)
+
+	// JSON-RPC errors keep the code of the server
+	got = client.CallRaw(`{"jsonrpc":"2.0","id":3,"method":"eth_revert","params":[]}`)
+	expected = `{"jsonrpc":"2.0","id":3,"error":{"code":-32015,"message":"execution reverted"}}`
+	require.Equal(t, expected, got)
+}
+
 func TestUnmarshalMessage(t *testing.T) {
 	body := json.RawMessage(`{"jsonrpc": "2.0", "method": "subtract", "params": {"subtrahend": 23, "minuend": 42}}`)
 	got, err := unmarshalMessage(body)
@@ -146,3 +210,40 @@ func TestIsBatch(t *testing.T) {
 		})
 	}
 }
+
+func TestCallRawBatchResponseIDs(t *testing.T) {
+	client, err := NewClient(nil, params.UpstreamRPCConfig{})
+	require.NoError(t, err)
+
+	client.RegisterHandler("eth_test", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	client.RegisterHandler("eth_fail", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+
+	got := client.CallRaw(`[
+		{"jsonrpc":"2.0","id":7,"method":"eth_test","params":[]},
+		{"jsonrpc":"2.0","id":3,"method":"eth_fail","params":[]},
+		{"jsonrpc":"2.0","id":"a","method":"eth_test","params":[]},
+		{"jsonrpc":"2.0","id":1,"method":"eth_test","params":[]}
+	]`)
+
+	var responses []struct {
+		ID json.RawMessage `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(got), &responses))
+	require.Len(t, responses, 4)
+	for i, id := range []string{`7`, `3`, `"a"`, `1`} {
+		require.Equal(t, id, string(responses[i].ID))
+	}
+}
+
+func TestCheckResponseID(t *testing.T) {
+	require.NoError(t, checkResponseID(json.RawMessage(`{"jsonrpc":"2.0","id": 42,"result":1}`), json.RawMessage(`42`)))
+	require.NoError(t, checkResponseID(json.RawMessage(`{"jsonrpc":"2.0","id":{"a": 1},"result":1}`), json.RawMessage(`{"a":1}`)))
+	require.EqualError(t,
+		checkResponseID(json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":1}`), json.RawMessage(`2`)),
+		"response to request 2 has id 1")
+	require.Error(t, checkResponseID(json.RawMessage(`{"jsonrpc":"2.0","result":1}`), json.RawMessage(`2`)))
+}
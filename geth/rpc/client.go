@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"reflect"
 	"sync"
 
@@ -13,6 +15,9 @@ import (
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
 )
 
+// ErrUpstreamDisabled is returned when an operation requires the upstream, but it's disabled.
+var ErrUpstreamDisabled = errors.New("upstream is disabled")
+
 // Handler defines handler for RPC methods.
 type Handler func(context.Context, ...interface{}) (interface{}, error)
 
@@ -23,13 +28,16 @@ type Client struct {
 	upstreamEnabled bool
 	upstreamURL     string
 
-	local    *gethrpc.Client
-	upstream *gethrpc.Client
+	local      *gethrpc.Client
+	upstreamMx sync.RWMutex // mx guards upstream
+	upstream   caller
 
 	router *router
 
 	handlersMx sync.RWMutex       // mx guards handlers
 	handlers   map[string]Handler // locally registered handlers
+
+	base *Client // client whose handlers are used as well, see WithUpstream
 }
 
 // NewClient initializes Client and tries to connect to both,
@@ -48,7 +56,7 @@ func NewClient(client *gethrpc.Client, upstream params.UpstreamRPCConfig) (*Clie
 	if upstream.Enabled {
 		c.upstreamEnabled = upstream.Enabled
 		c.upstreamURL = upstream.URL
-		c.upstream, err = gethrpc.Dial(c.upstreamURL)
+		c.upstream, err = dialUpstream(c.upstreamURL)
 		if err != nil {
 			return nil, fmt.Errorf("dial upstream server: %s", err)
 		}
@@ -85,11 +93,142 @@ func (c *Client) CallContext(ctx context.Context, result interface{}, method str
 	}
 
 	if c.router.routeRemote(method) {
-		return c.upstream.CallContext(ctx, result, method, args...)
+		return c.upstreamCaller().CallContext(ctx, result, method, args...)
 	}
 	return c.local.CallContext(ctx, result, method, args...)
 }
 
+// BatchCallContext sends all given requests as a single batch and waits for
+// the server to return a response for all of them.
+//
+// A single batch is sent only if all requests are routed to the same node.
+// Otherwise, i.e. if requests are split between the local node and the
+// upstream, or if any of the methods has a handler registered with
+// RegisterHandler, requests are executed one by one, each in its own
+// round trip. Errors of individual requests are set in the Error field
+// of the corresponding BatchElem.
+func (c *Client) BatchCallContext(ctx context.Context, b []gethrpc.BatchElem) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	if dest, ok := c.batchDestination(b); ok {
+		if bc, ok := dest.(batchCaller); ok {
+			return bc.BatchCallContext(ctx, b)
+		}
+	}
+
+	for i := range b {
+		b[i].Error = c.CallContext(ctx, b[i].Result, b[i].Method, b[i].Args...)
+	}
+
+	return nil
+}
+
+// batchDestination returns a node all requests are routed to.
+// It returns false if requests have different destinations
+// or any of them is handled locally.
+func (c *Client) batchDestination(b []gethrpc.BatchElem) (caller, bool) {
+	var remote int
+	for _, elem := range b {
+		if _, ok := c.handler(elem.Method); ok {
+			return nil, false
+		}
+
+		if c.router.routeRemote(elem.Method) {
+			remote++
+		}
+	}
+
+	switch remote {
+	case 0:
+		return c.local, true
+	case len(b):
+		return c.upstreamCaller(), true
+	}
+
+	return nil, false
+}
+
+// SetHTTPTransport replaces the transport used to communicate
+// with the upstream server. It's supported only for HTTP upstream endpoints.
+func (c *Client) SetHTTPTransport(transport http.RoundTripper) error {
+	if !c.upstreamEnabled {
+		return ErrUpstreamDisabled
+	}
+
+	u, err := url.Parse(c.upstreamURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("HTTP transport is not supported for upstream scheme '%s'", u.Scheme)
+	}
+
+	c.upstreamMx.Lock()
+	defer c.upstreamMx.Unlock()
+
+	c.upstream = newHTTPClient(c.upstreamURL, transport)
+
+	return nil
+}
+
+// WithUpstream returns a client routing requests like a client with the upstream
+// enabled and rawurl as its URL, e.g. to send requests of DApps to an external
+// provider while the local node handles the rest. Requests which are not routed
+// to the upstream go to the local node of c, and handlers registered with c are used.
+func (c *Client) WithUpstream(rawurl string) (*Client, error) {
+	upstream, err := dialUpstream(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("dial upstream server: %s", err)
+	}
+
+	return &Client{
+		upstreamEnabled: true,
+		upstreamURL:     rawurl,
+		local:           c.local,
+		upstream:        upstream,
+		router:          newRouter(true),
+		handlers:        make(map[string]Handler),
+		base:            c,
+	}, nil
+}
+
+// Close closes the connection to the upstream server of a client returned
+// by WithUpstream. The connection to the local node is shared with the base
+// client and stays open. Requests to the upstream fail after Close.
+func (c *Client) Close() {
+	if c.base == nil {
+		return
+	}
+
+	if closer, ok := c.upstreamCaller().(interface {
+		Close()
+	}); ok {
+		closer.Close()
+	}
+}
+
+// upstreamCaller is a concurrently safe method to get the upstream client.
+func (c *Client) upstreamCaller() caller {
+	c.upstreamMx.RLock()
+	defer c.upstreamMx.RUnlock()
+
+	return c.upstream
+}
+
+// dialUpstream connects to the upstream server.
+// It returns nil interface value if the connection fails.
+func dialUpstream(rawurl string) (caller, error) {
+	client, err := gethrpc.Dial(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
 // RegisterHandler registers local handler for specific RPC method.
 //
 // If method is registered, it will be executed with given handler and
@@ -127,8 +266,12 @@ func (c *Client) callMethod(ctx context.Context, result interface{}, handler Han
 // handler is a concurrently safe method to get registered handler by name.
 func (c *Client) handler(method string) (Handler, bool) {
 	c.handlersMx.RLock()
-	defer c.handlersMx.RUnlock()
 	handler, ok := c.handlers[method]
+	c.handlersMx.RUnlock()
+
+	if !ok && c.base != nil {
+		return c.base.handler(method)
+	}
 	return handler, ok
 }
 
@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// caller is implemented by clients that can perform JSON-RPC calls.
+type caller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// batchCaller is implemented by clients that can perform batched JSON-RPC calls.
+type batchCaller interface {
+	BatchCallContext(ctx context.Context, b []gethrpc.BatchElem) error
+}
+
+// httpClient performs JSON-RPC calls over HTTP using
+// a configurable http.Client. Unlike gethrpc.Client, it allows
+// to control the transport, e.g. to use a proxy or tune a connection pool.
+type httpClient struct {
+	url    string
+	client *http.Client
+}
+
+// newHTTPClient returns httpClient that sends requests to url
+// using the given transport.
+func newHTTPClient(url string, transport http.RoundTripper) *httpClient {
+	return &httpClient{
+		url:    url,
+		client: &http.Client{Transport: transport},
+	}
+}
+
+// httpResponse is a JSON-RPC response received over HTTP.
+type httpResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonError      `json:"error"`
+}
+
+// CallContext performs a JSON-RPC call with the given arguments
+// and unmarshals into result if no error occurred.
+func (c *httpClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	msg, err := newHTTPRequest(1, method, args)
+	if err != nil {
+		return err
+	}
+
+	var resp httpResponse
+	if err := c.post(ctx, msg, &resp); err != nil {
+		return err
+	}
+
+	return resp.unmarshalResult(result)
+}
+
+// BatchCallContext sends all given requests in a single HTTP request.
+// Errors of individual requests are set in the Error field
+// of the corresponding BatchElem.
+func (c *httpClient) BatchCallContext(ctx context.Context, b []gethrpc.BatchElem) error {
+	msgs := make([]*jsonrpcRequest, len(b))
+	for i, elem := range b {
+		msg, err := newHTTPRequest(i+1, elem.Method, elem.Args)
+		if err != nil {
+			return err
+		}
+		msgs[i] = msg
+	}
+
+	var resps []httpResponse
+	if err := c.post(ctx, msgs, &resps); err != nil {
+		return err
+	}
+
+	byID := make(map[string]httpResponse, len(resps))
+	for _, resp := range resps {
+		byID[string(resp.ID)] = resp
+	}
+
+	for i := range b {
+		resp, ok := byID[string(msgs[i].ID)]
+		if !ok {
+			b[i].Error = fmt.Errorf("missing response to request %s", msgs[i].ID)
+			continue
+		}
+		b[i].Error = resp.unmarshalResult(b[i].Result)
+	}
+
+	return nil
+}
+
+// newHTTPRequest returns a JSON-RPC request with the given ID.
+func newHTTPRequest(id int, method string, args []interface{}) (*jsonrpcRequest, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	params, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonrpcRequest{
+		jsonrpcMessage: jsonrpcMessage{
+			Version: jsonrpcVersion,
+			ID:      json.RawMessage(strconv.Itoa(id)),
+		},
+		Method: method,
+		Params: params,
+	}, nil
+}
+
+// post sends msg to the server and decodes the response into v.
+func (c *httpClient) post(ctx context.Context, msg interface{}, v interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// unmarshalResult unmarshals the result of a response into result,
+// or returns the error of the response.
+func (r httpResponse) unmarshalResult(result interface{}) error {
+	switch {
+	case r.Error != nil:
+		return r.Error
+	case len(r.Result) == 0:
+		return gethrpc.ErrNoResult
+	case result == nil:
+		return nil
+	}
+
+	return json.Unmarshal(r.Result, result)
+}
+
+// Error implements error interface.
+func (e *jsonError) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns JSON-RPC error code.
+// It implements gethrpc.Error interface.
+func (e *jsonError) ErrorCode() int {
+	return e.Code
+}
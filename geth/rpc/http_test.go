@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientBatchCall(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		var msgs []jsonrpcRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msgs))
+
+		// responses may come in any order
+		resps := make([]interface{}, 0, len(msgs))
+		for i := len(msgs) - 1; i >= 0; i-- {
+			var args []string
+			require.NoError(t, json.Unmarshal(msgs[i].Params, &args))
+			if args[0] == "0x0" {
+				resps = append(resps, jsonrpcErrorResponse{jsonrpcMessage: msgs[i].jsonrpcMessage, Error: jsonError{Code: -32000, Message: "unknown account"}})
+				continue
+			}
+			resps = append(resps, jsonrpcSuccessfulResponse{jsonrpcMessage: msgs[i].jsonrpcMessage, Result: json.RawMessage(`"` + args[0] + `"`)})
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resps))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(nil, params.UpstreamRPCConfig{Enabled: true, URL: ts.URL})
+	require.NoError(t, err)
+	require.NoError(t, client.SetHTTPTransport(http.DefaultTransport))
+
+	results := make([]hexutil.Big, 3)
+	batch := []gethrpc.BatchElem{
+		{Method: "eth_getBalance", Args: []interface{}{"0x1"}, Result: &results[0]},
+		{Method: "eth_getBalance", Args: []interface{}{"0x0"}, Result: &results[1]},
+		{Method: "eth_getBalance", Args: []interface{}{"0x2"}, Result: &results[2]},
+	}
+	require.NoError(t, client.BatchCallContext(context.Background(), batch))
+
+	// all requests are sent in a single round trip
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	require.NoError(t, batch[0].Error)
+	require.Equal(t, int64(1), results[0].ToInt().Int64())
+	require.EqualError(t, batch[1].Error, "unknown account")
+	require.NoError(t, batch[2].Error)
+	require.Equal(t, int64(2), results[2].ToInt().Int64())
+}
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrSubscriptionsUnsupported is returned when a subscription is routed
+// to a node which doesn't support them, e.g. an upstream reached over
+// a custom HTTP transport.
+var ErrSubscriptionsUnsupported = errors.New("subscriptions are not supported")
+
+// subscriber is implemented by clients that can create subscriptions.
+type subscriber interface {
+	Subscribe(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (*gethrpc.ClientSubscription, error)
+}
+
+// Subscribe calls the "<namespace>_subscribe" method with the given arguments,
+// registering a subscription. Notifications are sent to the given channel.
+// The subscription is routed like other calls of the method, but locally
+// registered handlers are not used.
+//
+// See gethrpc.Client.Subscribe for details.
+func (c *Client) Subscribe(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (*gethrpc.ClientSubscription, error) {
+	var s subscriber
+	if c.router.routeRemote(namespace + "_subscribe") {
+		s, _ = c.upstreamCaller().(subscriber)
+	} else if c.local != nil {
+		s = c.local
+	}
+
+	if s == nil {
+		return nil, ErrSubscriptionsUnsupported
+	}
+
+	return s.Subscribe(ctx, namespace, channel, args...)
+}
@@ -251,6 +251,9 @@ func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password
 
 	chainID := big.NewInt(int64(config.NetworkID))
 	nonce := uint64(txCount)
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	}
 	gasPrice := (*big.Int)(args.GasPrice)
 	data := []byte(args.Data)
 	value := (*big.Int)(args.Value)
@@ -2,17 +2,30 @@ package txqueue
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/golang/mock/gomock"
 
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
 	. "github.com/status-im/status-go/testing"
 )
 
@@ -277,3 +290,81 @@ func (s *TxQueueTestSuite) TestDiscardTransaction() {
 	// Transaction should be already removed from the queue.
 	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
 }
+
+func (s *TxQueueTestSuite) TestSendTransactionReturnsLocalHash() {
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	rawTxc := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []string        `json:"params"`
+		}
+		s.NoError(json.NewDecoder(r.Body).Decode(&req))
+
+		var result string
+		switch req.Method {
+		case "eth_getTransactionCount":
+			result = "0x0"
+		case "eth_sendRawTransaction":
+			rawTxc <- req.Params[0]
+			result = "0x" + strings.Repeat("0", 64)
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"%s"}`, req.ID, result)
+	}))
+	defer ts.Close()
+
+	gethClient, err := gethrpc.Dial(ts.URL)
+	s.NoError(err)
+	rpcClient, err := rpc.NewClient(gethClient, params.UpstreamRPCConfig{})
+	s.NoError(err)
+
+	dataDir, err := ioutil.TempDir("", "status-txqueue-test")
+	s.NoError(err)
+	defer os.RemoveAll(dataDir) //nolint: errcheck
+
+	config, err := params.NewNodeConfig(dataDir, params.RopstenNetworkID, true)
+	s.NoError(err)
+	config.UpstreamConfig.Enabled = true
+
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(config, nil).AnyTimes()
+	s.nodeManagerMock.EXPECT().RPCClient().Return(rpcClient).AnyTimes()
+	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
+		Address:    from,
+		AccountKey: &keystore.Key{PrivateKey: key},
+	}, nil).AnyTimes()
+	s.accountManagerMock.EXPECT().VerifyAccountPassword(config.KeyStoreDir, from.String(), TestConfig.Account1.Password).Return(nil, nil)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {
+		go func() {
+			_, err := txQueueManager.CompleteTransaction(queuedTx.ID, TestConfig.Account1.Password)
+			s.NoError(err)
+		}()
+	})
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {
+		s.NoError(err)
+	})
+
+	rpcClient.RegisterHandler("eth_sendTransaction", txQueueManager.SendTransactionRPCHandler)
+
+	var result string
+	s.NoError(rpcClient.Call(&result, "eth_sendTransaction", map[string]interface{}{
+		"from":     from.Hex(),
+		"to":       TestConfig.Account2.Address,
+		"gas":      "0x5208",
+		"gasPrice": "0x1",
+	}))
+
+	// The caller gets the hash of the locally signed transaction, not the upstream response.
+	rawTx := new(types.Transaction)
+	s.NoError(rlp.DecodeBytes(gethcommon.FromHex(<-rawTxc), rawTx))
+	s.Equal(rawTx.Hash().Hex(), result)
+}